@@ -21,12 +21,22 @@
 package filter
 
 import (
+	"crypto/sha256"
+	"fmt"
 	"net"
 	"sync"
+	"time"
 
+	"github.com/Psiphon-Inc/conduit/cli/internal/logging"
+	"github.com/Psiphon-Inc/conduit/cli/internal/metrics"
 	"github.com/oschwald/geoip2-golang"
 )
 
+// defaultFilterDebugSampleRate bounds per-connection debug logging to
+// one in every N decisions, so a busy proxy doesn't flood stdout.
+// Override via SetLogger with a differently-sampled logging.Logger.
+const defaultFilterDebugSampleRate = 100
+
 // CountryFilter filters connections based on country
 type CountryFilter struct {
 	db               *geoip2.Reader
@@ -37,6 +47,15 @@ type CountryFilter struct {
 	allowedCount int64
 	blockedCount int64
 	relayCount   int64
+
+	// eventSink, if set via SetEventSink, receives a structured
+	// per-connection filter decision event alongside the aggregate
+	// stats above. clientIDSalt scopes ClientIDHash to this proxy
+	// instance so hashes aren't correlatable across deployments.
+	eventSink    metrics.EventSink
+	clientIDSalt []byte
+
+	log logging.Logger
 }
 
 // NewCountryFilter creates a new country filter
@@ -54,19 +73,44 @@ func NewCountryFilter(dbPath string, allowedCountries []string) (*CountryFilter,
 	return &CountryFilter{
 		db:               db,
 		allowedCountries: allowed,
+		log:              logging.NewSampledLogger(logging.Default().With("component", "filter"), defaultFilterDebugSampleRate),
 	}, nil
 }
 
-// IsAllowed checks if an IP is allowed based on country
-// Returns: allowed (bool), countryCode (string), isRelay (bool for private IPs)
-func (f *CountryFilter) IsAllowed(ipStr string) (bool, string, bool) {
+// SetLogger replaces the logger used for per-decision debug logging,
+// e.g. to change the sampling rate via logging.NewSampledLogger.
+func (f *CountryFilter) SetLogger(l logging.Logger) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.log = l
+}
+
+// SetEventSink installs the structured event sink that IsAllowed emits
+// per-connection filter decisions to, along with the salt used to hash
+// the connecting IP into Event.ClientIDHash. Pass a nil sink to
+// disable (the default).
+func (f *CountryFilter) SetEventSink(sink metrics.EventSink, clientIDSalt []byte) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.eventSink = sink
+	f.clientIDSalt = clientIDSalt
+}
+
+// IsAllowed checks if an IP is allowed based on country, returning the
+// same Decision type Policy.Evaluate returns so callers can label
+// metrics/events by MatchedRule regardless of which filter is in use.
+// ASN is always zero, since CountryFilter has no ASN database - use
+// Policy for ASN-based rules.
+func (f *CountryFilter) IsAllowed(ipStr string) Decision {
 	ip := net.ParseIP(ipStr)
 	if ip == nil {
 		// Invalid IP, block it
 		f.mu.Lock()
 		f.blockedCount++
 		f.mu.Unlock()
-		return false, "", false
+		decision := Decision{Allowed: false, MatchedRule: "invalid-ip"}
+		f.emitDecision(ipStr, decision, "invalid IP")
+		return decision
 	}
 
 	// Allow private/loopback IPs (TURN relay connections)
@@ -74,27 +118,68 @@ func (f *CountryFilter) IsAllowed(ipStr string) (bool, string, bool) {
 		f.mu.Lock()
 		f.relayCount++
 		f.mu.Unlock()
-		return true, "RELAY", true
+		decision := Decision{Allowed: true, MatchedRule: "relay", CountryCode: "RELAY", IsRelay: true}
+		f.emitDecision(ipStr, decision, "")
+		return decision
 	}
 
 	f.mu.Lock()
-	defer f.mu.Unlock()
-
 	record, err := f.db.Country(ip)
 	if err != nil || record.Country.IsoCode == "" {
 		// Can't determine country, block it
 		f.blockedCount++
-		return false, "UNKNOWN", false
+		f.mu.Unlock()
+		decision := Decision{Allowed: false, MatchedRule: "country-lookup-failed", CountryCode: "UNKNOWN"}
+		f.emitDecision(ipStr, decision, "country lookup failed")
+		return decision
 	}
 
 	countryCode := record.Country.IsoCode
 	if f.allowedCountries[countryCode] {
 		f.allowedCount++
-		return true, countryCode, false
+		f.mu.Unlock()
+		decision := Decision{Allowed: true, MatchedRule: fmt.Sprintf("country:%s", countryCode), CountryCode: countryCode}
+		f.emitDecision(ipStr, decision, "")
+		return decision
 	}
 
 	f.blockedCount++
-	return false, countryCode, false
+	f.mu.Unlock()
+	decision := Decision{Allowed: false, MatchedRule: "country-not-allowed", CountryCode: countryCode}
+	f.emitDecision(ipStr, decision, "country not in allow list")
+	return decision
+}
+
+// emitDecision best-effort emits an EventFilterDecision event for one
+// IsAllowed call, and logs it at debug level (sampled, see SetLogger).
+// reason is only meaningful for blocks; it is empty for allowed/relay
+// connections.
+func (f *CountryFilter) emitDecision(ipStr string, decision Decision, reason string) {
+	f.mu.RLock()
+	sink := f.eventSink
+	salt := f.clientIDSalt
+	log := f.log
+	f.mu.RUnlock()
+
+	if decision.Allowed {
+		reason = ""
+	}
+	if log != nil {
+		log.Debug("filter decision", "decision", decision.Allowed, "country", decision.CountryCode, "relay", decision.IsRelay, "reason", reason)
+	}
+
+	if sink == nil {
+		return
+	}
+
+	hash := sha256.Sum256(append(append([]byte(nil), salt...), []byte(ipStr)...))
+	_ = sink.Emit(metrics.Event{
+		Type:         metrics.EventFilterDecision,
+		TimestampNS:  time.Now().UnixNano(),
+		CountryCode:  decision.CountryCode,
+		ClientIDHash: hash[:],
+		Reason:       reason,
+	})
 }
 
 // GetStats returns the current filter statistics
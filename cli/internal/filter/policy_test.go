@@ -0,0 +1,178 @@
+/*
+ * Copyright (c) 2026, Psiphon Inc.
+ * All rights reserved.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package filter
+
+import (
+	"net/netip"
+	"testing"
+)
+
+func mustPrefix(t *testing.T, s string) netip.Prefix {
+	t.Helper()
+	p, err := netip.ParsePrefix(s)
+	if err != nil {
+		t.Fatalf("netip.ParsePrefix(%q): %v", s, err)
+	}
+	return p
+}
+
+// TestRuleMatches exercises each RuleMatch kind in isolation, including
+// the cases that should NOT match.
+func TestRuleMatches(t *testing.T) {
+	cidrRule := compiledRule{Rule: Rule{Match: MatchCIDR}, prefix: mustPrefix(t, "10.0.0.0/8")}
+	asnRule := compiledRule{Rule: Rule{Match: MatchASN}, asn: 64500}
+	countryRule := compiledRule{Rule: Rule{Match: MatchCountry, Value: "CA"}}
+	privateRule := compiledRule{Rule: Rule{Match: MatchPrivate}}
+
+	tests := []struct {
+		name        string
+		rule        compiledRule
+		ip          string
+		countryCode string
+		asn         uint
+		want        bool
+	}{
+		{"cidr inside", cidrRule, "10.1.2.3", "", 0, true},
+		{"cidr outside", cidrRule, "11.1.2.3", "", 0, false},
+		{"asn match", asnRule, "1.2.3.4", "", 64500, true},
+		{"asn mismatch", asnRule, "1.2.3.4", "", 64501, false},
+		{"asn zero never matches", asnRule, "1.2.3.4", "", 0, false},
+		{"country match", countryRule, "1.2.3.4", "CA", 0, true},
+		{"country mismatch", countryRule, "1.2.3.4", "US", 0, false},
+		{"country empty never matches", countryRule, "1.2.3.4", "", 0, false},
+		{"private loopback", privateRule, "127.0.0.1", "", 0, true},
+		{"private rfc1918", privateRule, "192.168.1.1", "", 0, true},
+		{"private public ip", privateRule, "8.8.8.8", "", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ip, err := netip.ParseAddr(tt.ip)
+			if err != nil {
+				t.Fatalf("netip.ParseAddr(%q): %v", tt.ip, err)
+			}
+			if got := ruleMatches(tt.rule, ip, tt.countryCode, tt.asn); got != tt.want {
+				t.Errorf("ruleMatches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestDecisionForOrdering checks that the first matching rule wins even
+// when a later rule would also match, and that each matched rule's
+// Action maps to the expected Allowed/IsRelay combination.
+func TestDecisionForOrdering(t *testing.T) {
+	rules := []compiledRule{
+		{Rule: Rule{Action: ActionDeny, Match: MatchCIDR, Value: "10.0.0.0/8"}, prefix: mustPrefix(t, "10.0.0.0/8")},
+		{Rule: Rule{Action: ActionAllow, Match: MatchCountry, Value: "CA"}},
+		{Rule: Rule{Action: ActionRelay, Match: MatchPrivate}},
+	}
+
+	ip := netip.MustParseAddr("10.1.2.3")
+	decision := decisionFor(rules, ip, "CA", 0)
+
+	if decision.Allowed {
+		t.Errorf("expected the first matching rule (cidr deny) to win over the later country-allow rule, got Allowed=true")
+	}
+	if decision.MatchedRule != "cidr:10.0.0.0/8" {
+		t.Errorf("MatchedRule = %q, want %q", decision.MatchedRule, "cidr:10.0.0.0/8")
+	}
+}
+
+// TestDecisionForRelayAction checks that an ActionRelay match is
+// reported as both Allowed and IsRelay.
+func TestDecisionForRelayAction(t *testing.T) {
+	rules := []compiledRule{
+		{Rule: Rule{Action: ActionRelay, Match: MatchPrivate}},
+	}
+
+	decision := decisionFor(rules, netip.MustParseAddr("127.0.0.1"), "", 0)
+
+	if !decision.Allowed || !decision.IsRelay {
+		t.Errorf("decision = %+v, want Allowed=true IsRelay=true", decision)
+	}
+}
+
+// TestDecisionForDefaultDeny checks that an IP matching no rule is
+// denied with MatchedRule "default-deny".
+func TestDecisionForDefaultDeny(t *testing.T) {
+	rules := []compiledRule{
+		{Rule: Rule{Action: ActionAllow, Match: MatchCountry, Value: "CA"}},
+	}
+
+	decision := decisionFor(rules, netip.MustParseAddr("8.8.8.8"), "US", 64500)
+
+	if decision.Allowed {
+		t.Errorf("expected default-deny, got Allowed=true")
+	}
+	if decision.MatchedRule != "default-deny" {
+		t.Errorf("MatchedRule = %q, want %q", decision.MatchedRule, "default-deny")
+	}
+	if decision.CountryCode != "US" || decision.ASN != 64500 {
+		t.Errorf("default-deny decision should still carry the looked-up country/ASN, got %+v", decision)
+	}
+}
+
+// TestASNLabelUnderTopN checks that every observed ASN gets its own
+// label while the distinct count is at or below topNASN.
+func TestASNLabelUnderTopN(t *testing.T) {
+	p := &Policy{topNASN: 3, asnCounts: map[uint]int64{100: 5, 200: 1}}
+
+	if got := p.ASNLabel(100); got != "100" {
+		t.Errorf("ASNLabel(100) = %q, want %q", got, "100")
+	}
+	if got := p.ASNLabel(200); got != "200" {
+		t.Errorf("ASNLabel(200) = %q, want %q", got, "200")
+	}
+}
+
+// TestASNLabelTopNBucketing checks that once more distinct ASNs than
+// topNASN have been observed, only the most frequently seen ones keep
+// their own label and the rest bucket into "other".
+func TestASNLabelTopNBucketing(t *testing.T) {
+	p := &Policy{
+		topNASN: 2,
+		asnCounts: map[uint]int64{
+			100: 50, // top
+			200: 40, // top
+			300: 10, // long tail
+		},
+	}
+
+	if got := p.ASNLabel(100); got != "100" {
+		t.Errorf("ASNLabel(100) = %q, want %q (top-N)", got, "100")
+	}
+	if got := p.ASNLabel(200); got != "200" {
+		t.Errorf("ASNLabel(200) = %q, want %q (top-N)", got, "200")
+	}
+	if got := p.ASNLabel(300); got != "other" {
+		t.Errorf("ASNLabel(300) = %q, want %q (long tail)", got, "other")
+	}
+}
+
+// TestASNLabelZeroIsUnlabeled checks that an ASN of 0 (no ASN database
+// configured, or lookup failed) never gets its own label or "other".
+func TestASNLabelZeroIsUnlabeled(t *testing.T) {
+	p := &Policy{topNASN: 1, asnCounts: map[uint]int64{100: 1}}
+
+	if got := p.ASNLabel(0); got != "" {
+		t.Errorf("ASNLabel(0) = %q, want empty string", got)
+	}
+}
@@ -1,16 +1,128 @@
+// Package logging provides a leveled, structured logger for the
+// Conduit service, backed by log/slog, plus Printf/Println adapters
+// that preserve older unstructured call sites.
 package logging
 
 import (
 	"fmt"
-	"time"
+	"log/slog"
+	"os"
 )
 
+// Level is a logging verbosity level, ordered the same as slog's.
+type Level = slog.Level
+
+const (
+	LevelDebug = slog.LevelDebug
+	LevelInfo  = slog.LevelInfo
+	LevelWarn  = slog.LevelWarn
+	LevelError = slog.LevelError
+)
+
+// Format selects the slog.Handler a Logger is built on.
+type Format string
+
+const (
+	FormatText Format = "text"
+	FormatJSON Format = "json"
+)
+
+// Logger is the structured logging interface used across the Conduit
+// service. With returns a Logger that always includes the given
+// key/value fields on every subsequent call, mirroring slog.Logger.With.
+type Logger interface {
+	Debug(msg string, fields ...any)
+	Info(msg string, fields ...any)
+	Warn(msg string, fields ...any)
+	Error(msg string, fields ...any)
+	With(fields ...any) Logger
+}
+
+// slogLogger is the default Logger implementation, backed by log/slog.
+type slogLogger struct {
+	l *slog.Logger
+}
+
+// NewLogger builds a Logger at the given level, writing to stdout as
+// either text or JSON.
+func NewLogger(level Level, format Format) Logger {
+	opts := &slog.HandlerOptions{Level: level}
+
+	var handler slog.Handler
+	if format == FormatJSON {
+		handler = slog.NewJSONHandler(os.Stdout, opts)
+	} else {
+		handler = slog.NewTextHandler(os.Stdout, opts)
+	}
+
+	return &slogLogger{l: slog.New(handler)}
+}
+
+func (s *slogLogger) Debug(msg string, fields ...any) { s.l.Debug(msg, fields...) }
+func (s *slogLogger) Info(msg string, fields ...any)  { s.l.Info(msg, fields...) }
+func (s *slogLogger) Warn(msg string, fields ...any)  { s.l.Warn(msg, fields...) }
+func (s *slogLogger) Error(msg string, fields ...any) { s.l.Error(msg, fields...) }
+
+func (s *slogLogger) With(fields ...any) Logger {
+	return &slogLogger{l: s.l.With(fields...)}
+}
+
+// defaultLogger is configured from CONDUIT_LOG_FORMAT/CONDUIT_LOG_LEVEL
+// at package init, following the same env-var-default convention as
+// cli/scripts/monitor's envOr* flag helpers. A --log-level CLI flag
+// belongs in the conduit binary's own flag parsing, which isn't part
+// of this snapshot (only cli/scripts/monitor's separate flag set is);
+// SetDefault lets that binary's main wire one in once it exists.
+var defaultLogger = NewLogger(levelFromEnv("CONDUIT_LOG_LEVEL", LevelInfo), formatFromEnv("CONDUIT_LOG_FORMAT", FormatText))
+
+// SetDefault replaces the package-level Logger used by Printf/Println
+// and Default.
+func SetDefault(l Logger) {
+	defaultLogger = l
+}
+
+// Default returns the current package-level Logger.
+func Default() Logger {
+	return defaultLogger
+}
+
+func levelFromEnv(name string, def Level) Level {
+	switch os.Getenv(name) {
+	case "debug":
+		return LevelDebug
+	case "info":
+		return LevelInfo
+	case "warn":
+		return LevelWarn
+	case "error":
+		return LevelError
+	default:
+		return def
+	}
+}
+
+func formatFromEnv(name string, def Format) Format {
+	switch Format(os.Getenv(name)) {
+	case FormatJSON:
+		return FormatJSON
+	case FormatText:
+		return FormatText
+	default:
+		return def
+	}
+}
+
+// Printf and Println below are legacy adapters preserving the package's
+// original two-function API for call sites that haven't moved to the
+// structured Logger interface. They log at info level through the
+// default Logger; callers that already embed a "[ERROR]"/"[WARN]"
+// prefix in their message keep that text as-is.
 const TimeFormat = "2006-01-02 15:04:05"
 
 func Printf(format string, args ...any) {
-	fmt.Printf("%s "+format, append([]any{time.Now().Format(TimeFormat)}, args...)...)
+	defaultLogger.Info(fmt.Sprintf(format, args...))
 }
 
 func Println(args ...any) {
-	fmt.Println(append([]any{time.Now().Format(TimeFormat)}, args...)...)
+	defaultLogger.Info(fmt.Sprintln(args...))
 }
@@ -0,0 +1,38 @@
+package logging
+
+import "sync/atomic"
+
+// sampledLogger wraps a Logger and only forwards every Nth Debug call,
+// so a hot per-connection path (e.g. filter decisions) can log at
+// debug level without overwhelming stdout under load. Info/Warn/Error
+// always pass through unsampled.
+type sampledLogger struct {
+	inner Logger
+	every uint64
+	count atomic.Uint64
+}
+
+// NewSampledLogger wraps inner so only one in every `every` Debug
+// calls is actually logged (every <= 1 disables sampling). The first
+// call in each window is always forwarded, matching a typical
+// reservoir-style debug sampler.
+func NewSampledLogger(inner Logger, every int) Logger {
+	if every <= 1 {
+		return inner
+	}
+	return &sampledLogger{inner: inner, every: uint64(every)}
+}
+
+func (s *sampledLogger) Debug(msg string, fields ...any) {
+	if s.count.Add(1)%s.every == 1 {
+		s.inner.Debug(msg, fields...)
+	}
+}
+
+func (s *sampledLogger) Info(msg string, fields ...any)  { s.inner.Info(msg, fields...) }
+func (s *sampledLogger) Warn(msg string, fields ...any)  { s.inner.Warn(msg, fields...) }
+func (s *sampledLogger) Error(msg string, fields ...any) { s.inner.Error(msg, fields...) }
+
+func (s *sampledLogger) With(fields ...any) Logger {
+	return &sampledLogger{inner: s.inner.With(fields...), every: s.every}
+}
@@ -0,0 +1,249 @@
+/*
+ * Copyright (c) 2026, Psiphon Inc.
+ * All rights reserved.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package metrics
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+)
+
+// EventType identifies the kind of structured event carried on an
+// EventSink. Readers must tolerate unknown values for forward
+// compatibility, so new types may only be appended.
+type EventType int32
+
+const (
+	EventUnknown EventType = iota
+	EventClientConnected
+	EventClientDisconnected
+	EventFilterDecision
+)
+
+// Event is one structured, per-connection telemetry record. It carries
+// strictly more detail than the aggregate Prometheus counters/gauges,
+// at the cost of not being scraped - a sink must be actively consuming
+// the stream to see these.
+type Event struct {
+	Type         EventType
+	TimestampNS  int64
+	CountryCode  string
+	ClientIDHash []byte // sha256 of a proxy-scoped salt + peer identifier
+	BytesUp      uint64
+	BytesDown    uint64
+	Reason       string // set for EventFilterDecision blocks
+
+	// TraceID, when non-empty, matches the trace_id exemplar attached to
+	// the Prometheus counter sample this event corresponds to (see
+	// Metrics.SetExemplarsEnabled), so an operator can jump from a
+	// Grafana panel straight to this record.
+	TraceID string
+}
+
+// EventSink is anything that can accept a stream of structured events.
+// Implementations must be safe for concurrent use, since events may be
+// emitted from multiple goroutines (per-connection handlers, the geo
+// aggregation loop, etc).
+type EventSink interface {
+	Emit(Event) error
+	Close() error
+}
+
+// StreamEventSink is the default EventSink: it writes each Event as a
+// dnstap-style frame - a fixed 4-byte big-endian length prefix followed
+// by a protobuf-encoded payload - over a Unix or TCP connection.
+type StreamEventSink struct {
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+// NewUnixEventSink dials a Unix-domain socket at path and returns an
+// EventSink that streams frames to it. The listener (e.g. the tap
+// command in cli/scripts/tap) is expected to already be listening.
+func NewUnixEventSink(path string) (*StreamEventSink, error) {
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("eventstream: failed to dial %s: %w", path, err)
+	}
+	return &StreamEventSink{conn: conn}, nil
+}
+
+// NewTCPEventSink dials a TCP listener at addr and returns an EventSink
+// that streams frames to it.
+func NewTCPEventSink(addr string) (*StreamEventSink, error) {
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("eventstream: failed to dial %s: %w", addr, err)
+	}
+	return &StreamEventSink{conn: conn}, nil
+}
+
+func (s *StreamEventSink) Emit(e Event) error {
+	payload := marshalEvent(e)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return writeFrame(s.conn, payload)
+}
+
+func (s *StreamEventSink) Close() error {
+	return s.conn.Close()
+}
+
+// writeFrame writes a dnstap-style frame: a 4-byte big-endian length
+// prefix followed by payload.
+func writeFrame(w io.Writer, payload []byte) error {
+	var lenBuf [4]byte
+	binary.BigEndian.PutUint32(lenBuf[:], uint32(len(payload)))
+	if _, err := w.Write(lenBuf[:]); err != nil {
+		return err
+	}
+	_, err := w.Write(payload)
+	return err
+}
+
+// ReadFrame reads one dnstap-style frame from r: a 4-byte big-endian
+// length prefix followed by its payload. It is exported for use by
+// stream readers (e.g. the tap command) living outside this package.
+func ReadFrame(r io.Reader) ([]byte, error) {
+	var lenBuf [4]byte
+	if _, err := io.ReadFull(r, lenBuf[:]); err != nil {
+		return nil, err
+	}
+	payload := make([]byte, binary.BigEndian.Uint32(lenBuf[:]))
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return nil, err
+	}
+	return payload, nil
+}
+
+// Protobuf field numbers for Event. Only append new fields; never
+// reuse or renumber, so older/newer readers stay compatible.
+const (
+	fieldEventType    = 1
+	fieldTimestampNS  = 2
+	fieldCountryCode  = 3
+	fieldClientIDHash = 4
+	fieldBytesUp      = 5
+	fieldBytesDown    = 6
+	fieldReason       = 7
+	fieldTraceID      = 8
+)
+
+const (
+	wireVarint = 0
+	wireBytes  = 2
+)
+
+// marshalEvent encodes e using the protobuf wire format directly,
+// without a generated message type, since the rest of this tree has no
+// protoc/codegen step. The encoding is wire-compatible with a .proto
+// message using the field numbers above.
+func marshalEvent(e Event) []byte {
+	buf := make([]byte, 0, 64)
+	buf = appendVarintField(buf, fieldEventType, uint64(e.Type))
+	buf = appendVarintField(buf, fieldTimestampNS, uint64(e.TimestampNS))
+	buf = appendBytesField(buf, fieldCountryCode, []byte(e.CountryCode))
+	buf = appendBytesField(buf, fieldClientIDHash, e.ClientIDHash)
+	buf = appendVarintField(buf, fieldBytesUp, e.BytesUp)
+	buf = appendVarintField(buf, fieldBytesDown, e.BytesDown)
+	buf = appendBytesField(buf, fieldReason, []byte(e.Reason))
+	buf = appendBytesField(buf, fieldTraceID, []byte(e.TraceID))
+	return buf
+}
+
+// UnmarshalEvent decodes a payload produced by marshalEvent. Unknown
+// field numbers are skipped (by their wire type) rather than rejected,
+// so a newer writer's extra fields don't break an older reader.
+func UnmarshalEvent(payload []byte) (Event, error) {
+	var e Event
+	for len(payload) > 0 {
+		tag, n := binary.Uvarint(payload)
+		if n <= 0 {
+			return e, fmt.Errorf("eventstream: malformed tag")
+		}
+		payload = payload[n:]
+
+		field := tag >> 3
+		wireType := tag & 0x7
+
+		switch wireType {
+		case wireVarint:
+			v, n := binary.Uvarint(payload)
+			if n <= 0 {
+				return e, fmt.Errorf("eventstream: malformed varint")
+			}
+			payload = payload[n:]
+			switch field {
+			case fieldEventType:
+				e.Type = EventType(v)
+			case fieldTimestampNS:
+				e.TimestampNS = int64(v)
+			case fieldBytesUp:
+				e.BytesUp = v
+			case fieldBytesDown:
+				e.BytesDown = v
+			}
+		case wireBytes:
+			l, n := binary.Uvarint(payload)
+			if n <= 0 {
+				return e, fmt.Errorf("eventstream: malformed length")
+			}
+			payload = payload[n:]
+			if uint64(len(payload)) < l {
+				return e, fmt.Errorf("eventstream: truncated field %d", field)
+			}
+			v := payload[:l]
+			payload = payload[l:]
+			switch field {
+			case fieldCountryCode:
+				e.CountryCode = string(v)
+			case fieldClientIDHash:
+				e.ClientIDHash = append([]byte(nil), v...)
+			case fieldReason:
+				e.Reason = string(v)
+			case fieldTraceID:
+				e.TraceID = string(v)
+			}
+		default:
+			return e, fmt.Errorf("eventstream: unsupported wire type %d for field %d", wireType, field)
+		}
+	}
+	return e, nil
+}
+
+func appendVarintField(buf []byte, field int, v uint64) []byte {
+	if v == 0 {
+		return buf
+	}
+	buf = binary.AppendUvarint(buf, uint64(field)<<3|wireVarint)
+	return binary.AppendUvarint(buf, v)
+}
+
+func appendBytesField(buf []byte, field int, v []byte) []byte {
+	if len(v) == 0 {
+		return buf
+	}
+	buf = binary.AppendUvarint(buf, uint64(field)<<3|wireBytes)
+	buf = binary.AppendUvarint(buf, uint64(len(v)))
+	return append(buf, v...)
+}
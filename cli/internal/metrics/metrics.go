@@ -22,11 +22,18 @@ package metrics
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net"
 	"net/http"
+	"os"
+	"os/signal"
+	"sort"
 	"sync"
+	"syscall"
 	"time"
 
 	"github.com/Psiphon-Inc/conduit/cli/internal/geo"
@@ -48,8 +55,35 @@ type Metrics struct {
 	IsLive            prometheus.Gauge
 	MaxClients        prometheus.Gauge
 	BandwidthLimit    prometheus.Gauge
-	BytesUploaded     prometheus.Gauge
-	BytesDownloaded   prometheus.Gauge
+
+	// BytesUploaded/BytesDownloaded are Counters, not Gauges: a gauge
+	// re-Set to a cumulative total breaks rate()/increase() across a
+	// process restart, since Prometheus can't detect a counter reset on
+	// a gauge. SetBytesUploaded/SetBytesDownloaded still take the
+	// latest cumulative total (matching their old gauge-era call sites)
+	// and track the delta themselves, the same way UpdateGeo does for
+	// geoPrevious.
+	BytesUploaded   prometheus.Counter
+	BytesDownloaded prometheus.Counter
+
+	// Session lifecycle histograms, recorded via Observe* from the
+	// conduit runtime's client-connect/disconnect callbacks. These
+	// enable quantile-based SLO dashboards that the gauge-only metrics
+	// above can't support.
+	SessionDurationSeconds  prometheus.Histogram
+	HandshakeLatencySeconds prometheus.Histogram
+	BytesPerSession         prometheus.Histogram
+
+	// flowBytesUploaded/flowBytesDownloaded are native histograms of
+	// per-flow transfer sizes, recorded at connection close via
+	// ObserveFlowBytesUploaded/ObserveFlowBytesDownloaded, so operators
+	// get p50/p95/p99 transfer sizes without a label per flow.
+	flowBytesUploaded   prometheus.Histogram
+	flowBytesDownloaded prometheus.Histogram
+
+	bytesMu             sync.Mutex
+	bytesUploadedPrev   float64
+	bytesDownloadedPrev float64
 
 	// Geo metrics (by country)
 	geoConnectedClients   *prometheus.GaugeVec
@@ -57,15 +91,112 @@ type Metrics struct {
 	geoBytesUploadedVec   *prometheus.CounterVec
 	geoBytesDownloadedVec *prometheus.CounterVec
 
+	// Per-peer byte accounting, bounded by peerLRU so a noisy or
+	// compromised client can't cause unbounded label cardinality. See
+	// AddPeerBytes/WithPeerLRUCapacity.
+	peerBytesUploadedVec   *prometheus.CounterVec
+	peerBytesDownloadedVec *prometheus.CounterVec
+	peerLRU                *peerLRU
+
 	// Info
 	BuildInfo *prometheus.GaugeVec
 
 	registry *prometheus.Registry
 	server   *http.Server
+	log      logging.Logger
 
 	// State for counter delta tracking
 	geoMu       sync.Mutex
 	geoPrevious map[string]geo.Result // key: country_code
+
+	// eventSink, if set via SetEventSink, receives a structured,
+	// per-event telemetry stream in parallel with the aggregate
+	// counters/gauges above. Nil by default (no-op).
+	eventSink EventSink
+
+	// exemplarsEnabled gates attaching an OpenMetrics exemplar - a
+	// trace_id shared with the Event UpdateGeo emits to eventSink for
+	// the same update - to the geo_clients_total and
+	// geo_bytes_{uploaded,downloaded}_total counters it updates, via
+	// SetExemplarsEnabled. Disabled by default, since exemplars are a
+	// more expensive code path and are only rendered when a scraper
+	// actually asks for OpenMetrics.
+	exemplarsEnabled bool
+
+	// probes backs /readyz: each registered ReadinessProbe is checked on
+	// every request, and any that report not-ready are listed in the
+	// 503 response body.
+	probesMu sync.RWMutex
+	probes   map[string]ReadinessProbe
+
+	// quitEnabled/onQuit back the optional POST /-/quit endpoint.
+	quitMu      sync.Mutex
+	quitEnabled bool
+	onQuit      func()
+
+	// inFlight tracks requests currently being served by the /metrics
+	// handler, so Shutdown can wait for a slow scrape to finish
+	// rendering instead of cutting it off at ctx's deadline alone.
+	inFlight sync.WaitGroup
+
+	// snapshots is non-nil when New was called with
+	// WithSnapshotTracking, backing SnapshotsHandler.
+	snapshots *trackRegistry
+}
+
+// ReadinessProbe reports whether a subsystem (the broker client, a
+// filter.CountryFilter, the geo collector, etc.) is ready to serve
+// traffic. Ready returns ok=false and a human-readable reason when not;
+// the reason is surfaced verbatim in the /readyz response body.
+type ReadinessProbe interface {
+	Ready() (ok bool, reason string)
+}
+
+// RegisterProbe registers a named ReadinessProbe that /readyz checks on
+// every request. Registering a second probe under a name already in use
+// replaces the first.
+func (m *Metrics) RegisterProbe(name string, p ReadinessProbe) {
+	m.probesMu.Lock()
+	defer m.probesMu.Unlock()
+	if m.probes == nil {
+		m.probes = make(map[string]ReadinessProbe)
+	}
+	m.probes[name] = p
+}
+
+// SetQuitEnabled gates the optional POST /-/quit endpoint (404 when
+// disabled, the default). When enabled, a request to it calls onQuit,
+// which should trigger the caller's own graceful shutdown - there is no
+// top-level conduit main binary in this snapshot for this package to
+// own that shutdown itself, so the caller supplies the callback (see
+// WaitForShutdownSignal for the matching SIGTERM-driven path).
+func (m *Metrics) SetQuitEnabled(enabled bool, onQuit func()) {
+	m.quitMu.Lock()
+	defer m.quitMu.Unlock()
+	m.quitEnabled = enabled
+	m.onQuit = onQuit
+}
+
+// SetEventSink installs the structured event sink that UpdateGeo emits
+// connect/disconnect events to. Pass nil to disable (the default).
+func (m *Metrics) SetEventSink(sink EventSink) {
+	m.geoMu.Lock()
+	defer m.geoMu.Unlock()
+	m.eventSink = sink
+}
+
+// SetExemplarsEnabled toggles whether UpdateGeo attaches an OpenMetrics
+// exemplar to the geo_clients_total and geo_bytes_{uploaded,downloaded}_total
+// counters it updates. When enabled, each update that emits a
+// connect/disconnect Event to an installed eventSink generates one
+// trace_id and uses it both as the exemplar and as that Event's TraceID;
+// updates that emit no Event get no exemplar. An operator can jump from a
+// sampled point on a Grafana panel to the exact event-stream record it
+// came from.
+func (m *Metrics) SetExemplarsEnabled(enabled bool) {
+	m.geoMu.Lock()
+	defer m.geoMu.Unlock()
+	m.exemplarsEnabled = enabled
 }
 
 // GaugeFuncs holds functions that compute metrics at scrape time
@@ -74,16 +205,88 @@ type GaugeFuncs struct {
 	GetIdleSeconds   func() float64
 }
 
+// options accumulates the effect of the Options passed to New. Some
+// (WithoutGoCollectors) need to take effect before the registry/Metrics
+// are constructed; others (WithSnapshotTracking) need a constructed
+// Metrics to attach to, so they're deferred into postInit and run once
+// New has a *Metrics to hand them.
+type options struct {
+	disableGoCollectors bool
+	peerLRUCapacity     int
+	postInit            []func(*Metrics)
+}
+
+// Option configures optional behavior passed to New.
+type Option func(*options)
+
+// WithoutGoCollectors skips registering the Go runtime and process
+// collectors (go_gc_*, go_sched_latencies_seconds, process_cpu_seconds_total,
+// etc.), for embedded or test builds that don't want that metric volume.
+func WithoutGoCollectors() Option {
+	return func(o *options) {
+		o.disableGoCollectors = true
+	}
+}
+
+// defaultHistogramBuckets is used for any HistogramBuckets field left
+// nil, matching Prometheus's own recommended shape for latency/size
+// distributions that span a couple of orders of magnitude.
+var defaultHistogramBuckets = prometheus.ExponentialBuckets(0.1, 2, 12)
+
+// HistogramBuckets configures bucket boundaries for the client-session
+// lifecycle histograms New wires up. A nil field falls back to
+// defaultHistogramBuckets.
+type HistogramBuckets struct {
+	SessionDurationSeconds  []float64
+	HandshakeLatencySeconds []float64
+	BytesPerSession         []float64
+}
+
+func (b HistogramBuckets) sessionDuration() []float64 {
+	if b.SessionDurationSeconds != nil {
+		return b.SessionDurationSeconds
+	}
+	return defaultHistogramBuckets
+}
+
+func (b HistogramBuckets) handshakeLatency() []float64 {
+	if b.HandshakeLatencySeconds != nil {
+		return b.HandshakeLatencySeconds
+	}
+	return defaultHistogramBuckets
+}
+
+func (b HistogramBuckets) bytesPerSession() []float64 {
+	if b.BytesPerSession != nil {
+		return b.BytesPerSession
+	}
+	return defaultHistogramBuckets
+}
+
 // New creates a new Metrics instance with all metrics registered
-func New(gaugeFuncs GaugeFuncs) *Metrics {
+func New(gaugeFuncs GaugeFuncs, histogramBuckets HistogramBuckets, opts ...Option) *Metrics {
+	var cfg options
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
 	registry := prometheus.NewRegistry()
 
-	// Add standard Go metrics
-	registerCollector(collectors.NewGoCollector(), registry)
-	registerCollector(
-		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
-		registry,
-	)
+	// Add standard Go/process metrics, including the runtime/metrics-backed
+	// collection (go_gc_*, go_sched_latencies_seconds, go_memory_classes_*),
+	// unless the caller opted out via WithoutGoCollectors.
+	if !cfg.disableGoCollectors {
+		registerCollector(
+			collectors.NewGoCollector(
+				collectors.WithGoCollections(collectors.GoRuntimeMetricsCollection),
+			),
+			registry,
+		)
+		registerCollector(
+			collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+			registry,
+		)
+	}
 
 	m := &Metrics{
 		Announcing: newGauge(
@@ -134,19 +337,19 @@ func New(gaugeFuncs GaugeFuncs) *Metrics {
 			},
 			registry,
 		),
-		BytesUploaded: newGauge(
-			prometheus.GaugeOpts{
+		BytesUploaded: newCounter(
+			prometheus.CounterOpts{
 				Namespace: namespace,
 				Name:      "bytes_uploaded",
-				Help:      "Total number of bytes uploaded through the proxy",
+				Help:      "Total number of bytes uploaded through the proxy. Was a Gauge re-Set to a cumulative total prior to chunk1-5; now a proper Counter so rate()/increase() survive a process restart.",
 			},
 			registry,
 		),
-		BytesDownloaded: newGauge(
-			prometheus.GaugeOpts{
+		BytesDownloaded: newCounter(
+			prometheus.CounterOpts{
 				Namespace: namespace,
 				Name:      "bytes_downloaded",
-				Help:      "Total number of bytes downloaded through the proxy",
+				Help:      "Total number of bytes downloaded through the proxy. Was a Gauge re-Set to a cumulative total prior to chunk1-5; now a proper Counter so rate()/increase() survive a process restart.",
 			},
 			registry,
 		),
@@ -186,6 +389,24 @@ func New(gaugeFuncs GaugeFuncs) *Metrics {
 			[]string{"country_code"},
 			registry,
 		),
+		peerBytesUploadedVec: newCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "peer_bytes_uploaded_total",
+				Help:      "Total bytes uploaded by peer, bounded by a peer_id/protocol LRU (see WithPeerLRUCapacity)",
+			},
+			[]string{"peer_id", "protocol"},
+			registry,
+		),
+		peerBytesDownloadedVec: newCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "peer_bytes_downloaded_total",
+				Help:      "Total bytes downloaded by peer, bounded by a peer_id/protocol LRU (see WithPeerLRUCapacity)",
+			},
+			[]string{"peer_id", "protocol"},
+			registry,
+		),
 		BuildInfo: newGaugeVec(
 			prometheus.GaugeOpts{
 				Namespace: namespace,
@@ -196,9 +417,63 @@ func New(gaugeFuncs GaugeFuncs) *Metrics {
 			registry,
 		),
 
+		SessionDurationSeconds: newHistogram(
+			prometheus.HistogramOpts{
+				Namespace: namespace,
+				Subsystem: "client",
+				Name:      "session_duration_seconds",
+				Help:      "Distribution of client session durations, recorded when a client disconnects",
+				Buckets:   histogramBuckets.sessionDuration(),
+			},
+			registry,
+		),
+		HandshakeLatencySeconds: newHistogram(
+			prometheus.HistogramOpts{
+				Namespace: namespace,
+				Subsystem: "client",
+				Name:      "handshake_latency_seconds",
+				Help:      "Distribution of per-request handshake latency",
+				Buckets:   histogramBuckets.handshakeLatency(),
+			},
+			registry,
+		),
+		BytesPerSession: newHistogram(
+			prometheus.HistogramOpts{
+				Namespace: namespace,
+				Subsystem: "client",
+				Name:      "bytes_per_session",
+				Help:      "Distribution of total bytes (uploaded + downloaded) transferred over a client session",
+				Buckets:   histogramBuckets.bytesPerSession(),
+			},
+			registry,
+		),
+		flowBytesUploaded: newHistogram(
+			prometheus.HistogramOpts{
+				Namespace:                       namespace,
+				Name:                            "flow_bytes_uploaded",
+				Help:                            "Distribution of per-flow bytes uploaded, recorded at connection close",
+				NativeHistogramBucketFactor:     1.1,
+				NativeHistogramMaxBucketNumber:  100,
+				NativeHistogramMinResetDuration: time.Hour,
+			},
+			registry,
+		),
+		flowBytesDownloaded: newHistogram(
+			prometheus.HistogramOpts{
+				Namespace:                       namespace,
+				Name:                            "flow_bytes_downloaded",
+				Help:                            "Distribution of per-flow bytes downloaded, recorded at connection close",
+				NativeHistogramBucketFactor:     1.1,
+				NativeHistogramMaxBucketNumber:  100,
+				NativeHistogramMinResetDuration: time.Hour,
+			},
+			registry,
+		),
+
 		// Internal state
 		geoPrevious: make(map[string]geo.Result),
 		registry:    registry,
+		log:         logging.Default().With("component", "metrics"),
 	}
 
 	// Create GaugeFunc metrics (computed at scrape time)
@@ -231,6 +506,15 @@ func New(gaugeFuncs GaugeFuncs) *Metrics {
 			buildInfo.ValuesRev).
 		Set(1)
 
+	m.peerLRU = newPeerLRU(cfg.peerLRUCapacity, m.peerBytesUploadedVec, m.peerBytesDownloadedVec)
+
+	for _, f := range cfg.postInit {
+		f(m)
+	}
+	if m.snapshots != nil {
+		m.snapshots.start()
+	}
+
 	return m
 }
 
@@ -264,20 +548,80 @@ func (m *Metrics) SetIsLive(isLive bool) {
 	}
 }
 
-// SetBytesUploaded sets the bytes uploaded gauge
+// SetBytesUploaded takes the latest cumulative bytes-uploaded total and
+// adds the delta since the last call to the BytesUploaded counter,
+// mirroring the geoPrevious delta tracking in UpdateGeo. A decrease
+// (e.g. a counter reset upstream) is treated as a new baseline rather
+// than a negative Add, which Counter.Add rejects.
 func (m *Metrics) SetBytesUploaded(bytes float64) {
-	m.BytesUploaded.Set(bytes)
+	m.bytesMu.Lock()
+	defer m.bytesMu.Unlock()
+	if delta := bytes - m.bytesUploadedPrev; delta > 0 {
+		m.BytesUploaded.Add(delta)
+	}
+	m.bytesUploadedPrev = bytes
 }
 
-// SetBytesDownloaded sets the bytes downloaded gauge
+// SetBytesDownloaded takes the latest cumulative bytes-downloaded total
+// and adds the delta since the last call to the BytesDownloaded
+// counter. See SetBytesUploaded.
 func (m *Metrics) SetBytesDownloaded(bytes float64) {
-	m.BytesDownloaded.Set(bytes)
+	m.bytesMu.Lock()
+	defer m.bytesMu.Unlock()
+	if delta := bytes - m.bytesDownloadedPrev; delta > 0 {
+		m.BytesDownloaded.Add(delta)
+	}
+	m.bytesDownloadedPrev = bytes
+}
+
+// ObserveSessionDuration records one client session's duration, in
+// seconds, into the client_session_duration_seconds histogram. Call
+// this from the client-disconnect callback.
+func (m *Metrics) ObserveSessionDuration(seconds float64) {
+	m.SessionDurationSeconds.Observe(seconds)
+}
+
+// ObserveHandshakeLatency records one request's handshake latency, in
+// seconds, into the client_handshake_latency_seconds histogram.
+func (m *Metrics) ObserveHandshakeLatency(seconds float64) {
+	m.HandshakeLatencySeconds.Observe(seconds)
+}
+
+// ObserveBytesPerSession records one client session's total bytes
+// transferred into the client_bytes_per_session histogram. Call this
+// from the client-disconnect callback, alongside ObserveSessionDuration.
+func (m *Metrics) ObserveBytesPerSession(bytes float64) {
+	m.BytesPerSession.Observe(bytes)
+}
+
+// ObserveFlowBytesUploaded records the total bytes uploaded by one flow
+// into the flow_bytes_uploaded histogram. Call this at connection close.
+func (m *Metrics) ObserveFlowBytesUploaded(size float64) {
+	m.flowBytesUploaded.Observe(size)
+}
+
+// ObserveFlowBytesDownloaded records the total bytes downloaded by one
+// flow into the flow_bytes_downloaded histogram. Call this at
+// connection close.
+func (m *Metrics) ObserveFlowBytesDownloaded(size float64) {
+	m.flowBytesDownloaded.Observe(size)
 }
 
 // UpdateGeo updates geo-based metrics from the latest geo collector results.
 // It computes deltas against previously seen values to correctly increment
 // Prometheus counters, and resets the connected clients gauge each cycle
 // so that countries with no active connections are removed.
+//
+// When exemplars are enabled (see SetExemplarsEnabled) and a connect/
+// disconnect event fires for r this cycle, the same trace_id is used
+// both as the OpenMetrics exemplar on every counter r updates and as
+// the TraceID of the Event emitted to eventSink, so the two are
+// genuinely correlated - an operator can jump from a sampled point on
+// a Grafana panel to the exact event-stream record it came from. A
+// cycle that only moves byte counters without a connect/disconnect
+// transition gets no exemplar: this layer only sees per-country
+// aggregates, not individual flows, so there is no flow-scoped id to
+// attach one to.
 func (m *Metrics) UpdateGeo(results []geo.Result) {
 	m.geoMu.Lock()
 	defer m.geoMu.Unlock()
@@ -287,25 +631,104 @@ func (m *Metrics) UpdateGeo(results []geo.Result) {
 		m.geoConnectedClients.WithLabelValues(r.Code).Set(float64(r.Count))
 		prev := m.geoPrevious[r.Code]
 
+		traceID := m.emitGeoEventLocked(r, prev)
+
 		if delta := r.CountTotal - prev.CountTotal; delta > 0 {
-			m.geoTotalClients.WithLabelValues(r.Code).Add(float64(delta))
+			m.addWithExemplar(m.geoTotalClients.WithLabelValues(r.Code), float64(delta), traceID)
 		}
 		if delta := r.BytesUp - prev.BytesUp; delta > 0 {
-			m.geoBytesUploadedVec.WithLabelValues(r.Code).Add(float64(delta))
+			m.addWithExemplar(m.geoBytesUploadedVec.WithLabelValues(r.Code), float64(delta), traceID)
 		}
 		if delta := r.BytesDown - prev.BytesDown; delta > 0 {
-			m.geoBytesDownloadedVec.WithLabelValues(r.Code).Add(float64(delta))
+			m.addWithExemplar(m.geoBytesDownloadedVec.WithLabelValues(r.Code), float64(delta), traceID)
 		}
 		m.geoPrevious[r.Code] = r
 	}
 }
 
-// StartServer starts the HTTP server for Prometheus metrics
+// addWithExemplar adds delta to counter, attaching traceID as a
+// trace_id exemplar via prometheus.ExemplarAdder when traceID is
+// non-empty. Must be called with geoMu held.
+func (m *Metrics) addWithExemplar(counter prometheus.Counter, delta float64, traceID string) {
+	if traceID == "" {
+		counter.Add(delta)
+		return
+	}
+
+	adder, ok := counter.(prometheus.ExemplarAdder)
+	if !ok {
+		counter.Add(delta)
+		return
+	}
+
+	adder.AddWithExemplar(delta, prometheus.Labels{"trace_id": traceID})
+}
+
+// randomTraceID generates a short hex identifier to correlate an
+// exemplar with the Event emitted alongside it.
+func randomTraceID() string {
+	var b [8]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// emitGeoEventLocked best-effort emits a connect/disconnect event for
+// the net change in r.Count since prev, and returns the trace_id it
+// stamped onto that event so UpdateGeo can attach the identical id as
+// an exemplar on any counter it updates for r this cycle. It returns ""
+// - no exemplar should be attached - whenever no event is actually
+// emitted (no sink installed, or no connect/disconnect this cycle),
+// since an exemplar with no matching event record would be exactly the
+// uncorrelated id this mechanism exists to avoid. Must be called with
+// geoMu held. Note this layer only sees per-country aggregates, not
+// individual clients, so ClientIDHash is left unset here - a true
+// per-connection hash is only available where an actual peer/IP is in
+// scope, e.g. CountryFilter.IsAllowed.
+func (m *Metrics) emitGeoEventLocked(r, prev geo.Result) string {
+	if m.eventSink == nil {
+		return ""
+	}
+
+	event := Event{
+		TimestampNS: time.Now().UnixNano(),
+		CountryCode: r.Code,
+		BytesUp:     uint64(r.BytesUp - prev.BytesUp),
+		BytesDown:   uint64(r.BytesDown - prev.BytesDown),
+	}
+
+	switch {
+	case r.Count > prev.Count:
+		event.Type = EventClientConnected
+	case r.Count < prev.Count:
+		event.Type = EventClientDisconnected
+	default:
+		return ""
+	}
+
+	if m.exemplarsEnabled {
+		event.TraceID = randomTraceID()
+	}
+
+	if err := m.eventSink.Emit(event); err != nil {
+		m.log.Warn("failed to emit event", "err", err)
+		return ""
+	}
+	return event.TraceID
+}
+
+// StartServer starts the HTTP server for Prometheus metrics, plus
+// /healthz, /readyz, and (when enabled via SetQuitEnabled) /-/quit.
 func (m *Metrics) StartServer(addr string) error {
 	mux := http.NewServeMux()
-	mux.Handle("/metrics", promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{
+	mux.Handle("/metrics", m.trackInFlight(promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{
 		EnableOpenMetrics: true,
-	}))
+	})))
+	mux.HandleFunc("/healthz", m.handleHealthz)
+	mux.HandleFunc("/readyz", m.handleReadyz)
+	mux.HandleFunc("/-/quit", m.handleQuit)
+	if h := m.SnapshotsHandler(); h != nil {
+		mux.Handle("/metrics/snapshots", h)
+	}
 
 	m.server = &http.Server{
 		Addr:         addr,
@@ -325,18 +748,130 @@ func (m *Metrics) StartServer(addr string) error {
 	// Start server in background with the pre-created listener
 	go func() {
 		if err := m.server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
-			logging.Printf("[ERROR] Metrics server error: %v\n", err)
+			m.log.Error("metrics server error", "addr", addr, "err", err)
 		}
 	}()
 
 	return nil
 }
 
-// Shutdown gracefully shuts down the metrics server
-func (m *Metrics) Shutdown(ctx context.Context) error {
-	if m.server != nil {
-		return m.server.Shutdown(ctx)
+// trackInFlight wraps h so Shutdown's drain wait can observe requests
+// that are still being served.
+func (m *Metrics) trackInFlight(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		m.inFlight.Add(1)
+		defer m.inFlight.Done()
+		h.ServeHTTP(w, r)
+	})
+}
+
+// handleHealthz reports process liveness unconditionally: if this
+// handler can run, the process is alive.
+func (m *Metrics) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte("ok"))
+}
+
+// handleReadyz reports readiness as the AND of every registered
+// ReadinessProbe, responding 503 with a JSON body listing the failing
+// probes' names and reasons if any report not-ready.
+func (m *Metrics) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	m.probesMu.RLock()
+	failing := make([]string, 0, len(m.probes))
+	for name, p := range m.probes {
+		if ok, reason := p.Ready(); !ok {
+			if reason == "" {
+				reason = "not ready"
+			}
+			failing = append(failing, fmt.Sprintf("%s: %s", name, reason))
+		}
+	}
+	m.probesMu.RUnlock()
+	sort.Strings(failing)
+
+	w.Header().Set("Content-Type", "application/json")
+	if len(failing) > 0 {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_ = json.NewEncoder(w).Encode(map[string]any{"ready": false, "failing": failing})
+		return
 	}
+	_ = json.NewEncoder(w).Encode(map[string]any{"ready": true})
+}
 
-	return nil
+// handleQuit serves the optional graceful-shutdown trigger. It 404s
+// unless SetQuitEnabled(true, ...) has been called, and only accepts
+// POST, matching Prometheus's own /-/quit convention.
+func (m *Metrics) handleQuit(w http.ResponseWriter, r *http.Request) {
+	m.quitMu.Lock()
+	enabled := m.quitEnabled
+	onQuit := m.onQuit
+	m.quitMu.Unlock()
+
+	if !enabled {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	if onQuit != nil {
+		go onQuit()
+	}
+}
+
+// Shutdown gracefully shuts down the metrics server. If grace is
+// positive, it first disables keep-alives and waits up to
+// grace (or until ctx is done) for in-flight /metrics scrapes to
+// finish, rather than relying solely on http.Server.Shutdown(ctx) to
+// cut them off at ctx's own deadline. Pass grace <= 0 to skip the drain
+// wait.
+func (m *Metrics) Shutdown(ctx context.Context, grace time.Duration) error {
+	if m.snapshots != nil {
+		m.snapshots.stop()
+	}
+
+	if m.server == nil {
+		return nil
+	}
+
+	if grace > 0 {
+		m.server.SetKeepAlivesEnabled(false)
+
+		drained := make(chan struct{})
+		go func() {
+			m.inFlight.Wait()
+			close(drained)
+		}()
+
+		select {
+		case <-drained:
+		case <-time.After(grace):
+		case <-ctx.Done():
+		}
+	}
+
+	return m.server.Shutdown(ctx)
+}
+
+// WaitForShutdownSignal blocks until SIGINT or SIGTERM is received,
+// flips IsLive to 0, waits one scrapeInterval so an in-flight scraper
+// observes the change before the server stops answering, then shuts the
+// metrics server down with grace as the drain period. There is no
+// top-level conduit main binary in this snapshot to host a
+// signal-handling orchestrator, so this owns its own signal.Notify
+// channel rather than assuming a shared one exists; a future main can
+// call this directly, or use it as a reference for its own orchestrator.
+func (m *Metrics) WaitForShutdownSignal(ctx context.Context, scrapeInterval, grace time.Duration) error {
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	<-sigCh
+	signal.Stop(sigCh)
+
+	m.SetIsLive(false)
+	time.Sleep(scrapeInterval)
+
+	return m.Shutdown(ctx, grace)
 }
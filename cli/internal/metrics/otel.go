@@ -0,0 +1,110 @@
+/*
+ * Copyright (c) 2026, Psiphon Inc.
+ * All rights reserved.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package metrics
+
+import (
+	"context"
+	"fmt"
+
+	dto "github.com/prometheus/client_model/go"
+	otelmetric "go.opentelemetry.io/otel/metric"
+)
+
+// NewWithOTel builds a Metrics exactly as New does, then additionally
+// registers the unlabeled gauges (announcing, connecting_clients,
+// connected_clients, is_live, max_clients,
+// bandwidth_limit_bytes_per_second) and the bytes_uploaded/
+// bytes_downloaded counters as OpenTelemetry async instruments on a
+// meter obtained from provider. uptime_seconds, idle_seconds,
+// build_info, the per-country and per-peer vectors and the histograms
+// are exported through Prometheus only. This lets operators running conduit in an
+// OTel-instrumented environment can push metrics via OTLP without
+// scraping /metrics. Both exports stay live in parallel: the returned
+// Metrics' Prometheus registry is unaffected, and each OTel instrument's
+// callback simply reads the current value off its Prometheus
+// counterpart, so there is exactly one source of truth per metric.
+func NewWithOTel(ctx context.Context, gaugeFuncs GaugeFuncs, provider otelmetric.MeterProvider) (*Metrics, error) {
+	m := New(gaugeFuncs, HistogramBuckets{})
+
+	meter := provider.Meter(namespace)
+
+	type gaugeSource struct {
+		name string
+		g    interface{ Write(*dto.Metric) error }
+	}
+	for _, gs := range []gaugeSource{
+		{"announcing", m.Announcing},
+		{"connecting_clients", m.ConnectingClients},
+		{"connected_clients", m.ConnectedClients},
+		{"is_live", m.IsLive},
+		{"max_clients", m.MaxClients},
+		{"bandwidth_limit_bytes_per_second", m.BandwidthLimit},
+	} {
+		gs := gs
+		_, err := meter.Float64ObservableGauge(
+			namespace+"_"+gs.name,
+			otelmetric.WithFloat64Callback(func(_ context.Context, o otelmetric.Float64Observer) error {
+				o.Observe(readMetricValue(gs.g))
+				return nil
+			}),
+		)
+		if err != nil {
+			return nil, fmt.Errorf("metrics: failed to register otel gauge %s: %w", gs.name, err)
+		}
+	}
+
+	type counterSource struct {
+		name string
+		c    interface{ Write(*dto.Metric) error }
+	}
+	for _, cs := range []counterSource{
+		{"bytes_uploaded", m.BytesUploaded},
+		{"bytes_downloaded", m.BytesDownloaded},
+	} {
+		cs := cs
+		_, err := meter.Float64ObservableCounter(
+			namespace+"_"+cs.name,
+			otelmetric.WithFloat64Callback(func(_ context.Context, o otelmetric.Float64Observer) error {
+				o.Observe(readMetricValue(cs.c))
+				return nil
+			}),
+		)
+		if err != nil {
+			return nil, fmt.Errorf("metrics: failed to register otel counter %s: %w", cs.name, err)
+		}
+	}
+
+	return m, nil
+}
+
+// readMetricValue extracts the current value of a Prometheus gauge or
+// counter via its Write method, the only way to read a value back out
+// of the prometheus.Gauge/Counter interfaces without a full /metrics
+// scrape.
+func readMetricValue(w interface{ Write(*dto.Metric) error }) float64 {
+	var dm dto.Metric
+	if err := w.Write(&dm); err != nil {
+		return 0
+	}
+	if g := dm.GetGauge(); g != nil {
+		return g.GetValue()
+	}
+	return dm.GetCounter().GetValue()
+}
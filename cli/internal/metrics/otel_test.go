@@ -0,0 +1,74 @@
+/*
+ * Copyright (c) 2026, Psiphon Inc.
+ * All rights reserved.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package metrics
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+// TestNewWithOTelWiring mirrors TestRegistryWiring: it builds a Metrics
+// via NewWithOTel against an in-memory ManualReader and asserts every
+// expected instrument name shows up after a manual Collect, the OTel
+// analogue of gathering the Prometheus registry.
+func TestNewWithOTelWiring(t *testing.T) {
+	reader := metric.NewManualReader()
+	provider := metric.NewMeterProvider(metric.WithReader(reader))
+
+	ctx := context.Background()
+	if _, err := NewWithOTel(ctx, GaugeFuncs{
+		GetUptimeSeconds: func() float64 { return 123 },
+		GetIdleSeconds:   func() float64 { return 0 },
+	}, provider); err != nil {
+		t.Fatalf("NewWithOTel failed: %v", err)
+	}
+
+	var data metricdata.ResourceMetrics
+	if err := reader.Collect(ctx, &data); err != nil {
+		t.Fatalf("failed to collect otel metrics: %v", err)
+	}
+
+	found := make(map[string]struct{})
+	for _, sm := range data.ScopeMetrics {
+		for _, im := range sm.Metrics {
+			found[im.Name] = struct{}{}
+		}
+	}
+
+	expected := []string{
+		"conduit_announcing",
+		"conduit_connecting_clients",
+		"conduit_connected_clients",
+		"conduit_is_live",
+		"conduit_max_clients",
+		"conduit_bandwidth_limit_bytes_per_second",
+		"conduit_bytes_uploaded",
+		"conduit_bytes_downloaded",
+	}
+
+	for _, name := range expected {
+		if _, ok := found[name]; !ok {
+			t.Errorf("expected otel instrument %q to be registered", name)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+/*
+ * Copyright (c) 2026, Psiphon Inc.
+ * All rights reserved.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package metrics
+
+import (
+	"container/list"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// defaultPeerLRUCapacity bounds how many distinct (peer_id, protocol)
+// label sets AddPeerBytes tracks by default, see WithPeerLRUCapacity.
+const defaultPeerLRUCapacity = 1024
+
+// WithPeerLRUCapacity overrides the default cap (1024) on the number of
+// distinct (peer_id, protocol) label sets AddPeerBytes tracks at once.
+// A capacity <= 0 falls back to the default.
+func WithPeerLRUCapacity(capacity int) Option {
+	return func(o *options) {
+		o.peerLRUCapacity = capacity
+	}
+}
+
+// peerKey identifies one (peer_id, protocol) label set.
+type peerKey struct {
+	peerID string
+	proto  string
+}
+
+// peerLRU bounds the set of distinct peerKey label combinations
+// AddPeerBytes tracks, evicting the least-recently-used entry - and
+// deleting its labels from the underlying CounterVecs - once cap is
+// exceeded. This caps label cardinality regardless of how many distinct
+// peers a noisy or compromised client causes AddPeerBytes to be called
+// with.
+type peerLRU struct {
+	mu  sync.RWMutex
+	cap int
+
+	ll       *list.List
+	elements map[peerKey]*list.Element
+
+	uploaded   *prometheus.CounterVec
+	downloaded *prometheus.CounterVec
+}
+
+func newPeerLRU(capacity int, uploaded, downloaded *prometheus.CounterVec) *peerLRU {
+	if capacity <= 0 {
+		capacity = defaultPeerLRUCapacity
+	}
+	return &peerLRU{
+		cap:        capacity,
+		ll:         list.New(),
+		elements:   make(map[peerKey]*list.Element),
+		uploaded:   uploaded,
+		downloaded: downloaded,
+	}
+}
+
+// touch records k as most-recently-used, evicting the least-recently-used
+// entry first if k is new and the LRU is already at capacity. Callers
+// must hold mu.
+func (p *peerLRU) touch(k peerKey) {
+	if el, ok := p.elements[k]; ok {
+		p.ll.MoveToFront(el)
+		return
+	}
+
+	if p.ll.Len() >= p.cap {
+		p.evictOldest()
+	}
+
+	p.elements[k] = p.ll.PushFront(k)
+}
+
+// evictOldest removes the least-recently-used entry and deletes its
+// labels from both CounterVecs. Callers must hold mu.
+func (p *peerLRU) evictOldest() {
+	oldest := p.ll.Back()
+	if oldest == nil {
+		return
+	}
+
+	k := oldest.Value.(peerKey)
+	p.ll.Remove(oldest)
+	delete(p.elements, k)
+	p.uploaded.DeleteLabelValues(k.peerID, k.proto)
+	p.downloaded.DeleteLabelValues(k.peerID, k.proto)
+}
+
+// len returns the number of distinct peerKey label sets currently
+// tracked.
+func (p *peerLRU) len() int {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.ll.Len()
+}
+
+// AddPeerBytes increments the per-peer upload/download byte counters
+// for peerID/proto. The LRU bookkeeping and the counter increments it
+// guards are done under the same lock, so a concurrent eviction can
+// never interleave between recording a peer as in-use and incrementing
+// its counters - a race that has historically bitten similar
+// labeled-metric fan-in code in other SDKs.
+func (m *Metrics) AddPeerBytes(peerID, proto string, up, down uint64) {
+	key := peerKey{peerID: peerID, proto: proto}
+
+	m.peerLRU.mu.Lock()
+	defer m.peerLRU.mu.Unlock()
+
+	m.peerLRU.touch(key)
+	if up > 0 {
+		m.peerBytesUploadedVec.WithLabelValues(peerID, proto).Add(float64(up))
+	}
+	if down > 0 {
+		m.peerBytesDownloadedVec.WithLabelValues(peerID, proto).Add(float64(down))
+	}
+}
+
+// PeerLRULen returns the number of distinct (peer_id, protocol) label
+// sets AddPeerBytes is currently tracking, mainly for tests asserting
+// eviction behavior.
+func (m *Metrics) PeerLRULen() int {
+	return m.peerLRU.len()
+}
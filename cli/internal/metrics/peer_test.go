@@ -0,0 +1,123 @@
+/*
+ * Copyright (c) 2026, Psiphon Inc.
+ * All rights reserved.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package metrics
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func newTestMetrics(t *testing.T, peerLRUCapacity int) *Metrics {
+	t.Helper()
+	return New(GaugeFuncs{
+		GetUptimeSeconds: func() float64 { return 0 },
+		GetIdleSeconds:   func() float64 { return 0 },
+	}, HistogramBuckets{}, WithPeerLRUCapacity(peerLRUCapacity))
+}
+
+// TestAddPeerBytesRegistersLabels checks that a single AddPeerBytes
+// call registers both the uploaded/downloaded label-set children and
+// leaves them discoverable via a registry Gather.
+func TestAddPeerBytesRegistersLabels(t *testing.T) {
+	m := newTestMetrics(t, 0)
+
+	m.AddPeerBytes("peer-1", "webrtc", 100, 200)
+
+	mfs, err := m.registry.Gather()
+	if err != nil {
+		t.Fatalf("failed to gather metrics: %v", err)
+	}
+
+	found := make(map[string]struct{})
+	for _, mf := range mfs {
+		found[mf.GetName()] = struct{}{}
+	}
+	for _, name := range []string{"conduit_peer_bytes_uploaded_total", "conduit_peer_bytes_downloaded_total"} {
+		if _, ok := found[name]; !ok {
+			t.Errorf("expected metric %q to be registered after AddPeerBytes", name)
+		}
+	}
+
+	if got := m.PeerLRULen(); got != 1 {
+		t.Fatalf("expected 1 tracked peer, got %d", got)
+	}
+}
+
+// TestPeerLRUEviction checks that once the LRU cap is exceeded, the
+// least-recently-used peer is evicted and its labels removed from both
+// CounterVecs.
+func TestPeerLRUEviction(t *testing.T) {
+	m := newTestMetrics(t, 2)
+
+	m.AddPeerBytes("peer-1", "webrtc", 1, 1)
+	m.AddPeerBytes("peer-2", "webrtc", 1, 1)
+	// Touch peer-1 again so peer-2 becomes the least-recently-used.
+	m.AddPeerBytes("peer-1", "webrtc", 1, 1)
+	// Adding a third distinct peer should evict peer-2, not peer-1.
+	m.AddPeerBytes("peer-3", "webrtc", 1, 1)
+
+	if got := m.PeerLRULen(); got != 2 {
+		t.Fatalf("expected LRU to stay capped at 2, got %d", got)
+	}
+
+	if _, ok := m.peerLRU.elements[peerKey{peerID: "peer-2", proto: "webrtc"}]; ok {
+		t.Errorf("expected peer-2 to have been evicted as least-recently-used")
+	}
+	if _, ok := m.peerLRU.elements[peerKey{peerID: "peer-1", proto: "webrtc"}]; !ok {
+		t.Errorf("expected peer-1 to still be tracked after being touched")
+	}
+	if _, ok := m.peerLRU.elements[peerKey{peerID: "peer-3", proto: "webrtc"}]; !ok {
+		t.Errorf("expected peer-3 to be tracked after being added")
+	}
+}
+
+// TestAddPeerBytesConcurrent hammers AddPeerBytes from many goroutines
+// across a small, overlapping set of peer IDs, to be run with -race. It
+// only asserts the LRU stays within its configured capacity throughout -
+// the interesting property under -race is the absence of a data race,
+// not a specific final peer set.
+func TestAddPeerBytesConcurrent(t *testing.T) {
+	const (
+		capacity     = 16
+		goroutines   = 32
+		perGoroutine = 200
+		distinctIDs  = 64
+	)
+
+	m := newTestMetrics(t, capacity)
+
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < perGoroutine; i++ {
+				peerID := fmt.Sprintf("peer-%d", (g*perGoroutine+i)%distinctIDs)
+				m.AddPeerBytes(peerID, "webrtc", 1, 2)
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	if got := m.PeerLRULen(); got > capacity {
+		t.Fatalf("expected LRU to never exceed capacity %d, got %d", capacity, got)
+	}
+}
@@ -104,6 +104,53 @@ func newCounterVec(
 	return ev
 }
 
+// build and register a new Prometheus counter by accepting its options.
+func newCounter(
+	counterOpts prometheus.CounterOpts,
+	registry *prometheus.Registry,
+) prometheus.Counter {
+	ev := prometheus.NewCounter(counterOpts)
+
+	err := registry.Register(ev)
+	if err != nil {
+		var are prometheus.AlreadyRegisteredError
+		if ok := errors.As(err, &are); ok {
+			ev, ok = are.ExistingCollector.(prometheus.Counter)
+			if !ok {
+				panic("different metric type registration")
+			}
+		} else {
+			panic(err)
+		}
+	}
+
+	return ev
+}
+
+// build and register a new Prometheus histogram by accepting its
+// options.
+func newHistogram(
+	histogramOpts prometheus.HistogramOpts,
+	registry *prometheus.Registry,
+) prometheus.Histogram {
+	ev := prometheus.NewHistogram(histogramOpts)
+
+	err := registry.Register(ev)
+	if err != nil {
+		var are prometheus.AlreadyRegisteredError
+		if ok := errors.As(err, &are); ok {
+			ev, ok = are.ExistingCollector.(prometheus.Histogram)
+			if !ok {
+				panic("different metric type registration")
+			}
+		} else {
+			panic(err)
+		}
+	}
+
+	return ev
+}
+
 // registers or reuses a collector without crashing.
 func registerCollector(
 	ct prometheus.Collector,
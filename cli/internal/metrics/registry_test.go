@@ -31,7 +31,7 @@ func TestRegistryWiring(t *testing.T) {
 	m := New(GaugeFuncs{
 		GetUptimeSeconds: func() float64 { return 123 },
 		GetIdleSeconds:   func() float64 { return 0 },
-	})
+	}, HistogramBuckets{})
 
 	// gather registry metrics
 	mfs, err := m.registry.Gather()
@@ -63,6 +63,9 @@ func TestRegistryWiring(t *testing.T) {
 		"conduit_build_info",
 		"conduit_uptime_seconds",
 		"conduit_idle_seconds",
+		"conduit_client_session_duration_seconds",
+		"conduit_client_handshake_latency_seconds",
+		"conduit_client_bytes_per_session",
 	}
 
 	for _, name := range expected {
@@ -70,4 +73,37 @@ func TestRegistryWiring(t *testing.T) {
 			t.Errorf("expected metric %q to be registered in custom registry", name)
 		}
 	}
+
+	// subset check: the Go runtime/process collectors should be wired
+	// in by default.
+	goCollectorMetrics := []string{
+		"go_goroutines",
+		"go_gc_duration_seconds",
+		"process_resident_memory_bytes",
+	}
+	for _, name := range goCollectorMetrics {
+		if _, ok := found[name]; !ok {
+			t.Errorf("expected go collector metric %q to be registered by default", name)
+		}
+	}
+}
+
+// TestWithoutGoCollectors checks that WithoutGoCollectors skips
+// registering the Go runtime/process collectors.
+func TestWithoutGoCollectors(t *testing.T) {
+	m := New(GaugeFuncs{
+		GetUptimeSeconds: func() float64 { return 123 },
+		GetIdleSeconds:   func() float64 { return 0 },
+	}, HistogramBuckets{}, WithoutGoCollectors())
+
+	mfs, err := m.registry.Gather()
+	if err != nil {
+		t.Fatalf("failed to gather metrics: %v", err)
+	}
+
+	for _, mf := range mfs {
+		if mf.GetName() == "go_goroutines" {
+			t.Fatalf("expected go_goroutines to be absent when WithoutGoCollectors is set")
+		}
+	}
 }
@@ -0,0 +1,161 @@
+/*
+ * Copyright (c) 2026, Psiphon Inc.
+ * All rights reserved.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package metrics
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Snapshot is one point-in-time capture of the registry's single-valued
+// gauge/counter metrics, keyed by metric name.
+type Snapshot struct {
+	Timestamp time.Time          `json:"timestamp"`
+	Values    map[string]float64 `json:"values"`
+}
+
+// WithSnapshotTracking enables a rolling-window TrackRegistry: every
+// interval, it snapshots the current value of each unlabeled
+// gauge/counter in the registry and retains the last retain snapshots,
+// exposed as JSON by Metrics.SnapshotsHandler (mounted at
+// /metrics/snapshots by StartServer). This lets an operator view
+// short-term history - bandwidth over the last few minutes, client
+// counts, idle time - without running Prometheus.
+func WithSnapshotTracking(interval time.Duration, retain int) Option {
+	return func(o *options) {
+		o.postInit = append(o.postInit, func(m *Metrics) {
+			m.snapshots = newTrackRegistry(m.registry, interval, retain)
+		})
+	}
+}
+
+// trackRegistry is the rolling-window snapshot subsystem WithSnapshotTracking
+// wires onto a Metrics' Prometheus registry.
+type trackRegistry struct {
+	registry *prometheus.Registry
+	interval time.Duration
+	retain   int
+
+	mu        sync.Mutex
+	snapshots []Snapshot // oldest first, capped at retain
+
+	stopCh chan struct{}
+	doneCh chan struct{}
+}
+
+func newTrackRegistry(registry *prometheus.Registry, interval time.Duration, retain int) *trackRegistry {
+	if retain <= 0 {
+		retain = 1
+	}
+	return &trackRegistry{
+		registry: registry,
+		interval: interval,
+		retain:   retain,
+		stopCh:   make(chan struct{}),
+		doneCh:   make(chan struct{}),
+	}
+}
+
+// start runs the periodic snapshot loop in its own goroutine until stop
+// is called.
+func (t *trackRegistry) start() {
+	go func() {
+		defer close(t.doneCh)
+		ticker := time.NewTicker(t.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case now := <-ticker.C:
+				t.takeSnapshot(now)
+			case <-t.stopCh:
+				return
+			}
+		}
+	}()
+}
+
+// stop ends the periodic snapshot loop and waits for it to exit.
+func (t *trackRegistry) stop() {
+	close(t.stopCh)
+	<-t.doneCh
+}
+
+// takeSnapshot gathers the registry and records one Snapshot at now.
+// Vectors and histograms are skipped: a metric family needs exactly one
+// unlabeled sample for "metric name to numeric value" to be meaningful.
+func (t *trackRegistry) takeSnapshot(now time.Time) {
+	mfs, err := t.registry.Gather()
+	if err != nil {
+		return
+	}
+
+	values := make(map[string]float64)
+	for _, mf := range mfs {
+		if len(mf.Metric) != 1 || len(mf.Metric[0].Label) != 0 {
+			continue
+		}
+
+		metric := mf.Metric[0]
+		switch {
+		case metric.Gauge != nil:
+			values[mf.GetName()] = metric.Gauge.GetValue()
+		case metric.Counter != nil:
+			values[mf.GetName()] = metric.Counter.GetValue()
+		}
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.snapshots = append(t.snapshots, Snapshot{Timestamp: now, Values: values})
+	if len(t.snapshots) > t.retain {
+		t.snapshots = t.snapshots[len(t.snapshots)-t.retain:]
+	}
+}
+
+// list returns a copy of the currently retained snapshots, oldest first.
+func (t *trackRegistry) list() []Snapshot {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	out := make([]Snapshot, len(t.snapshots))
+	copy(out, t.snapshots)
+	return out
+}
+
+func (t *trackRegistry) handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(t.list())
+	})
+}
+
+// SnapshotsHandler returns the HTTP handler serving the rolling-window
+// snapshot JSON. It is only non-nil when New was called with
+// WithSnapshotTracking; StartServer mounts it at /metrics/snapshots
+// automatically when set.
+func (m *Metrics) SnapshotsHandler() http.Handler {
+	if m.snapshots == nil {
+		return nil
+	}
+	return m.snapshots.handler()
+}
@@ -0,0 +1,92 @@
+/*
+ * Copyright (c) 2026, Psiphon Inc.
+ * All rights reserved.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package metrics
+
+import (
+	"testing"
+	"time"
+)
+
+// TestTrackRegistrySnapshotContents advances a fake clock across
+// several manual snapshots and checks that the retained values and
+// timestamps match what was set on the registry at each point.
+func TestTrackRegistrySnapshotContents(t *testing.T) {
+	m := New(GaugeFuncs{
+		GetUptimeSeconds: func() float64 { return 0 },
+		GetIdleSeconds:   func() float64 { return 0 },
+	}, HistogramBuckets{})
+
+	tr := newTrackRegistry(m.registry, time.Second, 2)
+
+	fakeNow := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	m.SetAnnouncing(1)
+	tr.takeSnapshot(fakeNow)
+
+	fakeNow = fakeNow.Add(10 * time.Second)
+	m.SetAnnouncing(2)
+	tr.takeSnapshot(fakeNow)
+
+	fakeNow = fakeNow.Add(10 * time.Second)
+	m.SetAnnouncing(3)
+	tr.takeSnapshot(fakeNow)
+
+	snapshots := tr.list()
+	if len(snapshots) != 2 {
+		t.Fatalf("expected retain=2 to cap snapshots at 2, got %d", len(snapshots))
+	}
+
+	if !snapshots[0].Timestamp.Equal(fakeNow.Add(-10 * time.Second)) {
+		t.Errorf("expected the oldest retained snapshot to be the second one taken, got timestamp %v", snapshots[0].Timestamp)
+	}
+	if got := snapshots[0].Values["conduit_announcing"]; got != 2 {
+		t.Errorf("expected conduit_announcing=2 in the oldest retained snapshot, got %v", got)
+	}
+
+	if !snapshots[1].Timestamp.Equal(fakeNow) {
+		t.Errorf("expected the newest retained snapshot's timestamp to be %v, got %v", fakeNow, snapshots[1].Timestamp)
+	}
+	if got := snapshots[1].Values["conduit_announcing"]; got != 3 {
+		t.Errorf("expected conduit_announcing=3 in the newest retained snapshot, got %v", got)
+	}
+}
+
+// TestWithSnapshotTrackingWiring checks that the WithSnapshotTracking
+// option wires SnapshotsHandler, and that a disabled Metrics leaves it
+// nil.
+func TestWithSnapshotTrackingWiring(t *testing.T) {
+	m := New(GaugeFuncs{
+		GetUptimeSeconds: func() float64 { return 0 },
+		GetIdleSeconds:   func() float64 { return 0 },
+	}, HistogramBuckets{}, WithSnapshotTracking(time.Hour, 5))
+	defer m.snapshots.stop()
+
+	if m.SnapshotsHandler() == nil {
+		t.Fatal("expected SnapshotsHandler to be non-nil after WithSnapshotTracking")
+	}
+
+	without := New(GaugeFuncs{
+		GetUptimeSeconds: func() float64 { return 0 },
+		GetIdleSeconds:   func() float64 { return 0 },
+	}, HistogramBuckets{})
+	if without.SnapshotsHandler() != nil {
+		t.Fatal("expected SnapshotsHandler to be nil without WithSnapshotTracking")
+	}
+}
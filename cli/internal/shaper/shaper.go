@@ -0,0 +1,140 @@
+/*
+ * Copyright (c) 2026, Psiphon Inc.
+ * All rights reserved.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+// Package shaper provides live bandwidth shaping for the supervised
+// conduit child process, as an alternative to restarting it with
+// different --max-clients/--bandwidth flags every time a traffic
+// threshold is crossed.
+package shaper
+
+import (
+	"context"
+	"fmt"
+
+	"golang.org/x/time/rate"
+)
+
+// Backend selects how bandwidth limits are enforced.
+type Backend string
+
+const (
+	// BackendInproc shapes traffic with an in-process token bucket that
+	// a proxy relay would consult before forwarding bytes in each
+	// direction. Not yet usable: no relay exists to call WaitUpload/
+	// WaitDownload, so the monitor CLI refuses to select it (see
+	// validateConfig in cli/scripts/monitor).
+	BackendInproc Backend = "inproc"
+
+	// BackendTC shapes traffic out-of-process with Linux tc,
+	// so it applies even though conduit's own sockets are unaware of it.
+	BackendTC Backend = "tc"
+
+	// BackendNone disables shaping; callers fall back to whatever
+	// throttling strategy they already have (e.g. process restart).
+	BackendNone Backend = "none"
+)
+
+// Shaper live-tunes per-direction bandwidth limits for the child
+// process without restarting it.
+type Shaper interface {
+	// SetLimits updates the upload/download rate limits, in bytes per
+	// second, and the burst size, in bytes, for both directions.
+	// A limit of 0 means unlimited.
+	SetLimits(uploadBytesPerSec, downloadBytesPerSec float64, burstBytes int) error
+
+	// WaitUpload/WaitDownload block the given number of bytes in that direction
+	// through the shaper, consuming tokens from the appropriate
+	// limiter. It is a no-op for backends that don't shape in-process.
+	WaitUpload(n int) error
+	WaitDownload(n int) error
+
+	// Close releases any resources held by the shaper (sockets,
+	// tc qdiscs, etc).
+	Close() error
+}
+
+// New constructs a Shaper for the given backend. iface is only used by
+// the tc backend, to identify which network interface to attach
+// qdiscs to.
+func New(backend Backend, iface string) (Shaper, error) {
+	switch backend {
+	case BackendInproc, "":
+		return newInprocShaper(), nil
+	case BackendTC:
+		return newTCShaper(iface)
+	case BackendNone:
+		return noopShaper{}, nil
+	default:
+		return nil, fmt.Errorf("unknown shaper backend %q", backend)
+	}
+}
+
+// inprocShaper enforces bandwidth limits with an in-process
+// golang.org/x/time/rate.Limiter per direction. A caller that relays
+// the child's traffic through a local proxy is expected to call
+// WaitUpload/WaitDownload before forwarding each chunk - no such relay
+// exists yet, so this backend isn't reachable from the monitor CLI
+// (see validateConfig in cli/scripts/monitor) until one is built.
+type inprocShaper struct {
+	upload   *rate.Limiter
+	download *rate.Limiter
+}
+
+func newInprocShaper() *inprocShaper {
+	return &inprocShaper{
+		upload:   rate.NewLimiter(rate.Inf, 0),
+		download: rate.NewLimiter(rate.Inf, 0),
+	}
+}
+
+func (s *inprocShaper) SetLimits(uploadBytesPerSec, downloadBytesPerSec float64, burstBytes int) error {
+	setLimiter(s.upload, uploadBytesPerSec, burstBytes)
+	setLimiter(s.download, downloadBytesPerSec, burstBytes)
+	return nil
+}
+
+func setLimiter(l *rate.Limiter, bytesPerSec float64, burstBytes int) {
+	if bytesPerSec <= 0 {
+		l.SetLimit(rate.Inf)
+		l.SetBurst(0)
+		return
+	}
+	l.SetLimit(rate.Limit(bytesPerSec))
+	l.SetBurst(burstBytes)
+}
+
+func (s *inprocShaper) WaitUpload(n int) error {
+	return s.upload.WaitN(context.Background(), n)
+}
+
+func (s *inprocShaper) WaitDownload(n int) error {
+	return s.download.WaitN(context.Background(), n)
+}
+
+func (s *inprocShaper) Close() error {
+	return nil
+}
+
+// noopShaper is used when shaping is disabled; it never blocks.
+type noopShaper struct{}
+
+func (noopShaper) SetLimits(float64, float64, int) error { return nil }
+func (noopShaper) WaitUpload(int) error                  { return nil }
+func (noopShaper) WaitDownload(int) error                { return nil }
+func (noopShaper) Close() error                          { return nil }
@@ -0,0 +1,80 @@
+/*
+ * Copyright (c) 2026, Psiphon Inc.
+ * All rights reserved.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package shaper
+
+import (
+	"fmt"
+	"os/exec"
+)
+
+// tcShaper shapes traffic out-of-process using the Linux `tc` tool
+// with an HTB qdisc on the given interface, so it applies even when
+// the child process is not itself shaper-aware. Only the combined
+// upload+download rate is supported, since a single interface carries
+// both directions.
+type tcShaper struct {
+	iface string
+}
+
+func newTCShaper(iface string) (*tcShaper, error) {
+	if iface == "" {
+		return nil, fmt.Errorf("shaper: --iface is required for the tc backend")
+	}
+	if err := runTC("qdisc", "add", "dev", iface, "root", "handle", "1:", "htb", "default", "10"); err != nil {
+		return nil, fmt.Errorf("shaper: failed to install htb qdisc on %s: %w", iface, err)
+	}
+	// SetLimits only ever "class change"s 1:10, so it has to exist
+	// before the first throttle step; start it unlimited, same as the
+	// rateBps <= 0 case below.
+	if err := runTC("class", "add", "dev", iface, "parent", "1:", "classid", "1:10",
+		"htb", "rate", "10000mbit"); err != nil {
+		_ = runTC("qdisc", "del", "dev", iface, "root")
+		return nil, fmt.Errorf("shaper: failed to create htb class 1:10 on %s: %w", iface, err)
+	}
+	return &tcShaper{iface: iface}, nil
+}
+
+func (s *tcShaper) SetLimits(uploadBytesPerSec, downloadBytesPerSec float64, burstBytes int) error {
+	// tc classifies a single interface's egress queue, so the upload
+	// and download limits are combined into one class rate.
+	rateBps := uploadBytesPerSec + downloadBytesPerSec
+	if rateBps <= 0 {
+		return runTC("class", "change", "dev", s.iface, "parent", "1:", "classid", "1:10",
+			"htb", "rate", "10000mbit")
+	}
+	return runTC("class", "change", "dev", s.iface, "parent", "1:", "classid", "1:10",
+		"htb", "rate", fmt.Sprintf("%.0fbps", rateBps), "burst", fmt.Sprintf("%db", burstBytes))
+}
+
+func (s *tcShaper) WaitUpload(int) error   { return nil }
+func (s *tcShaper) WaitDownload(int) error { return nil }
+
+func (s *tcShaper) Close() error {
+	return runTC("qdisc", "del", "dev", s.iface, "root")
+}
+
+func runTC(args ...string) error {
+	cmd := exec.Command("tc", args...)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("tc %v: %w: %s", args, err, out)
+	}
+	return nil
+}
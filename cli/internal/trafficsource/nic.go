@@ -0,0 +1,95 @@
+/*
+ * Copyright (c) 2026, Psiphon Inc.
+ * All rights reserved.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package trafficsource
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// NICSource is a TrafficSource that reads per-interface counters
+// straight from the kernel via /proc/net/dev, instead of depending on
+// the child's Prometheus endpoint. This counts all traffic on the
+// interface - including TLS overhead and control traffic the metrics
+// endpoint doesn't see, and anything that happens before the metrics
+// server is up - at the cost of also counting traffic unrelated to
+// conduit if the interface is shared.
+type NICSource struct {
+	Iface string
+
+	procNetDevPath string // overridable in tests
+}
+
+func NewNICSource(iface string) *NICSource {
+	return &NICSource{
+		Iface:          iface,
+		procNetDevPath: "/proc/net/dev",
+	}
+}
+
+func (n *NICSource) Name() string { return "nic" }
+
+func (n *NICSource) TotalBytes() (int64, error) {
+	if n.Iface == "" {
+		return 0, fmt.Errorf("trafficsource: --iface is required for the nic traffic source")
+	}
+
+	f, err := os.Open(n.procNetDevPath)
+	if err != nil {
+		return 0, fmt.Errorf("trafficsource: failed to read %s: %w", n.procNetDevPath, err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		iface, rest, ok := strings.Cut(line, ":")
+		if !ok || strings.TrimSpace(iface) != n.Iface {
+			continue
+		}
+
+		fields := strings.Fields(rest)
+		// /proc/net/dev columns: bytes packets errs drops fifo frame
+		// compressed multicast | bytes packets errs drops fifo colls
+		// carrier compressed (receive | transmit)
+		if len(fields) < 16 {
+			return 0, fmt.Errorf("trafficsource: unexpected /proc/net/dev format for %s", n.Iface)
+		}
+
+		rxBytes, err := strconv.ParseInt(fields[0], 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("trafficsource: failed to parse rx bytes: %w", err)
+		}
+		txBytes, err := strconv.ParseInt(fields[8], 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("trafficsource: failed to parse tx bytes: %w", err)
+		}
+
+		return rxBytes + txBytes, nil
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, err
+	}
+
+	return 0, fmt.Errorf("trafficsource: interface %q not found in %s", n.Iface, n.procNetDevPath)
+}
@@ -0,0 +1,107 @@
+/*
+ * Copyright (c) 2026, Psiphon Inc.
+ * All rights reserved.
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+// Package trafficsource provides pluggable ways to measure the total
+// bytes transferred by the conduit child process, so traffic
+// accounting isn't solely dependent on the child's own Prometheus
+// metrics endpoint being up and accurate.
+package trafficsource
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// TrafficSource reports the cumulative number of bytes (upload plus
+// download) the child has transferred since it started. Implementations
+// are not expected to track deltas themselves - callers diff successive
+// calls, same as the original Prometheus-only scraper did.
+type TrafficSource interface {
+	// TotalBytes returns the cumulative bytes transferred so far.
+	TotalBytes() (int64, error)
+
+	// Name identifies the source, for logging when sources are
+	// compared side-by-side.
+	Name() string
+}
+
+// MetricsScraper is a TrafficSource backed by the child's Prometheus
+// /metrics endpoint, reading conduit_bytes_uploaded/downloaded.
+type MetricsScraper struct {
+	URL        string
+	HTTPClient *http.Client
+
+	// SimulateFailure, if set, is consulted before every scrape and
+	// can return a synthetic error to exercise unstable-network
+	// handling without a real flaky endpoint.
+	SimulateFailure func() error
+}
+
+func (m *MetricsScraper) Name() string { return "metrics" }
+
+func (m *MetricsScraper) TotalBytes() (int64, error) {
+	if m.SimulateFailure != nil {
+		if err := m.SimulateFailure(); err != nil {
+			return 0, err
+		}
+	}
+
+	resp, err := m.HTTPClient.Get(m.URL)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("metrics returned status %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, err
+	}
+
+	var up, down int64
+	for _, line := range strings.Split(string(body), "\n") {
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		// Use HasPrefix with a trailing space for exact metric name
+		// matching, to avoid matching "conduit_bytes_uploaded_total" etc.
+		if strings.HasPrefix(line, "conduit_bytes_uploaded ") {
+			if parts := strings.Fields(line); len(parts) >= 2 {
+				if val, err := strconv.ParseFloat(parts[1], 64); err == nil {
+					up = int64(val)
+				}
+			}
+		}
+		if strings.HasPrefix(line, "conduit_bytes_downloaded ") {
+			if parts := strings.Fields(line); len(parts) >= 2 {
+				if val, err := strconv.ParseFloat(parts[1], 64); err == nil {
+					down = int64(val)
+				}
+			}
+		}
+	}
+
+	return up + down, nil
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// reloadableFields are the subset of Config that can be changed live,
+// without restarting the supervisor. All fields are pointers so that a
+// partial config file only overrides the fields it sets.
+type reloadableFields struct {
+	TrafficLimitGB            *float64 `json:"trafficLimitGB,omitempty" yaml:"trafficLimitGB,omitempty"`
+	TrafficPeriodDays         *int     `json:"trafficPeriodDays,omitempty" yaml:"trafficPeriodDays,omitempty"`
+	BandwidthThresholdPercent *int     `json:"bandwidthThresholdPercent,omitempty" yaml:"bandwidthThresholdPercent,omitempty"`
+	MinConnections            *int     `json:"minConnections,omitempty" yaml:"minConnections,omitempty"`
+	MinBandwidthMbps          *float64 `json:"minBandwidthMbps,omitempty" yaml:"minBandwidthMbps,omitempty"`
+}
+
+// loadReloadableFields reads and decodes path as YAML or JSON based on
+// its extension (.json is treated as JSON, everything else as YAML,
+// since YAML is a superset of JSON anyway).
+func loadReloadableFields(path string) (*reloadableFields, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var f reloadableFields
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		if err := json.Unmarshal(data, &f); err != nil {
+			return nil, fmt.Errorf("failed to parse config file as JSON: %w", err)
+		}
+	} else {
+		if err := yaml.Unmarshal(data, &f); err != nil {
+			return nil, fmt.Errorf("failed to parse config file as YAML: %w", err)
+		}
+	}
+
+	return &f, nil
+}
+
+// reloadConfig re-reads cfg.ConfigFile and applies any set fields to
+// the running config under s.mu, validating the result before
+// committing it. It is the handler for both SIGHUP and the control
+// socket's POST /reload.
+func (s *Supervisor) reloadConfig() error {
+	if s.cfg.ConfigFile == "" {
+		return fmt.Errorf("no --config file configured")
+	}
+
+	fields, err := loadReloadableFields(s.cfg.ConfigFile)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Validate onto a copy so a bad file validation-fails without
+	// mutating the live config. Only the fields above are ever written
+	// back to s.cfg below - unlike the rest of Config, which is set once
+	// at startup and never touched again, so readers elsewhere don't need
+	// s.mu to observe it.
+	next := *s.cfg
+	if fields.TrafficLimitGB != nil {
+		next.TrafficLimitGB = *fields.TrafficLimitGB
+	}
+	if fields.TrafficPeriodDays != nil {
+		next.TrafficPeriodDays = *fields.TrafficPeriodDays
+	}
+	if fields.BandwidthThresholdPercent != nil {
+		next.BandwidthThresholdPercent = *fields.BandwidthThresholdPercent
+	}
+	if fields.MinConnections != nil {
+		next.MinConnections = *fields.MinConnections
+	}
+	if fields.MinBandwidthMbps != nil {
+		next.MinBandwidthMbps = *fields.MinBandwidthMbps
+	}
+
+	if err := validateConfig(&next); err != nil {
+		return fmt.Errorf("rejected config reload: %w", err)
+	}
+
+	// Write back only the reloadable fields, in place, rather than
+	// reassigning the whole struct: *s.cfg = next would touch every
+	// field's memory, including ones readers access without s.mu.
+	if fields.TrafficLimitGB != nil {
+		s.cfg.TrafficLimitGB = next.TrafficLimitGB
+	}
+	if fields.TrafficPeriodDays != nil {
+		s.cfg.TrafficPeriodDays = next.TrafficPeriodDays
+	}
+	if fields.BandwidthThresholdPercent != nil {
+		s.cfg.BandwidthThresholdPercent = next.BandwidthThresholdPercent
+	}
+	if fields.MinConnections != nil {
+		s.cfg.MinConnections = next.MinConnections
+	}
+	if fields.MinBandwidthMbps != nil {
+		s.cfg.MinBandwidthMbps = next.MinBandwidthMbps
+	}
+
+	log.Printf("[INFO] Reloaded config from %s: traffic-limit=%.1fGB traffic-period=%dd bandwidth-threshold=%d%% "+
+		"min-connections=%d min-bandwidth=%.1fMbps",
+		s.cfg.ConfigFile, s.cfg.TrafficLimitGB, s.cfg.TrafficPeriodDays, s.cfg.BandwidthThresholdPercent,
+		s.cfg.MinConnections, s.cfg.MinBandwidthMbps)
+
+	return nil
+}
+
+// resetPeriod resets the current traffic period as if it had expired
+// naturally, without waiting for checkTraffic's next tick.
+func (s *Supervisor) resetPeriod() {
+	s.mu.Lock()
+	s.state.PeriodStartTime = time.Now()
+	s.state.BytesUsed = 0
+	wasThrottled := s.state.IsThrottled
+	s.state.IsThrottled = false
+	s.lastScrapeTotal = 0
+	s.shaperStep = -1
+	if err := s.shaper.SetLimits(0, 0, 0); err != nil {
+		log.Printf("[WARN] Failed to reset shaper limits: %v", err)
+	}
+	if err := s.saveState(); err != nil {
+		log.Printf("[WARN] Failed to save state after manual reset: %v", err)
+	}
+	s.mu.Unlock()
+
+	if wasThrottled && (s.cfg.ShaperBackend == "" || s.cfg.ShaperBackend == "none") {
+		s.triggerRestart()
+	}
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ControlSocketName is the Unix-domain socket the control plane
+// listens on, relative to Config.DataDir.
+const ControlSocketName = "monitor.sock"
+
+type statusResponse struct {
+	PeriodStartTime           time.Time `json:"periodStartTime"`
+	BytesUsed                 int64     `json:"bytesUsed"`
+	IsThrottled               bool      `json:"isThrottled"`
+	TrafficLimitGB            float64   `json:"trafficLimitGB"`
+	TrafficPeriodDays         int       `json:"trafficPeriodDays"`
+	BandwidthThresholdPercent int       `json:"bandwidthThresholdPercent"`
+	MinConnections            int       `json:"minConnections"`
+	MinBandwidthMbps          float64   `json:"minBandwidthMbps"`
+	ChildPID                  int       `json:"childPID"`
+	UptimeSeconds             float64   `json:"uptimeSeconds"`
+}
+
+// startControlSocket binds the Unix-domain control socket at
+// ${DataDir}/monitor.sock and starts serving it in the background. The
+// caller is responsible for calling stopControlSocket on shutdown.
+func (s *Supervisor) startControlSocket() error {
+	socketPath := filepath.Join(s.cfg.DataDir, ControlSocketName)
+
+	// Remove a stale socket left behind by an unclean shutdown.
+	if err := os.Remove(socketPath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove stale control socket: %w", err)
+	}
+
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		return fmt.Errorf("failed to bind control socket %s: %w", socketPath, err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/status", s.handleStatus)
+	mux.HandleFunc("/reload", s.handleReload)
+	mux.HandleFunc("/reset-period", s.handleResetPeriod)
+	mux.HandleFunc("/throttle", s.handleThrottle)
+	mux.HandleFunc("/unthrottle", s.handleUnthrottle)
+	mux.HandleFunc("/restart-child", s.handleRestartChild)
+
+	s.controlServer = &http.Server{Handler: mux}
+
+	go func() {
+		if err := s.controlServer.Serve(listener); err != nil && err != http.ErrServerClosed {
+			log.Printf("[ERROR] Control socket error: %v", err)
+		}
+	}()
+
+	return nil
+}
+
+func (s *Supervisor) stopControlSocket() {
+	if s.controlServer == nil {
+		return
+	}
+	if err := s.controlServer.Close(); err != nil {
+		log.Printf("[WARN] Failed to close control socket: %v", err)
+	}
+	_ = os.Remove(filepath.Join(s.cfg.DataDir, ControlSocketName))
+}
+
+func (s *Supervisor) handleStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	s.mu.Lock()
+	resp := statusResponse{
+		PeriodStartTime:           s.state.PeriodStartTime,
+		BytesUsed:                 s.state.BytesUsed,
+		IsThrottled:               s.state.IsThrottled,
+		TrafficLimitGB:            s.cfg.TrafficLimitGB,
+		TrafficPeriodDays:         s.cfg.TrafficPeriodDays,
+		BandwidthThresholdPercent: s.cfg.BandwidthThresholdPercent,
+		MinConnections:            s.cfg.MinConnections,
+		MinBandwidthMbps:          s.cfg.MinBandwidthMbps,
+		UptimeSeconds:             time.Since(s.startTime).Seconds(),
+	}
+	if s.child != nil && s.child.Process != nil {
+		resp.ChildPID = s.child.Process.Pid
+	}
+	s.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func (s *Supervisor) handleReload(w http.ResponseWriter, r *http.Request) {
+	if !requirePost(w, r) {
+		return
+	}
+	if err := s.reloadConfig(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Supervisor) handleResetPeriod(w http.ResponseWriter, r *http.Request) {
+	if !requirePost(w, r) {
+		return
+	}
+	s.resetPeriod()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Supervisor) handleThrottle(w http.ResponseWriter, r *http.Request) {
+	if !requirePost(w, r) {
+		return
+	}
+
+	s.mu.Lock()
+	alreadyThrottled := s.state.IsThrottled
+	s.state.IsThrottled = true
+	if err := s.saveState(); err != nil {
+		log.Printf("[WARN] Failed to save state: %v", err)
+	}
+	s.mu.Unlock()
+
+	if !alreadyThrottled {
+		s.triggerRestart()
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Supervisor) handleUnthrottle(w http.ResponseWriter, r *http.Request) {
+	if !requirePost(w, r) {
+		return
+	}
+
+	s.mu.Lock()
+	wasThrottled := s.state.IsThrottled
+	s.state.IsThrottled = false
+	s.shaperStep = -1
+	if err := s.shaper.SetLimits(0, 0, 0); err != nil {
+		log.Printf("[WARN] Failed to reset shaper limits: %v", err)
+	}
+	if err := s.saveState(); err != nil {
+		log.Printf("[WARN] Failed to save state: %v", err)
+	}
+	s.mu.Unlock()
+
+	if wasThrottled {
+		s.triggerRestart()
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Supervisor) handleRestartChild(w http.ResponseWriter, r *http.Request) {
+	if !requirePost(w, r) {
+		return
+	}
+	s.triggerRestart()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func requirePost(w http.ResponseWriter, r *http.Request) bool {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return false
+	}
+	return true
+}
@@ -3,10 +3,9 @@ package main
 import (
 	"context"
 	"encoding/json"
-	"flag"
 	"fmt"
-	"io"
 	"log"
+	"math/rand"
 	"net/http"
 	"os"
 	"os/exec"
@@ -17,6 +16,10 @@ import (
 	"sync"
 	"syscall"
 	"time"
+
+	"github.com/Psiphon-Inc/conduit/cli/internal/shaper"
+	"github.com/Psiphon-Inc/conduit/cli/internal/trafficsource"
+	"github.com/spf13/pflag"
 )
 
 const (
@@ -32,15 +35,55 @@ const (
 
 	// HTTP client timeout for metrics scraping
 	httpTimeout = 5 * time.Second
+
+	// Defaults for the scrape retry/backoff and restart backoff knobs
+	DefaultScrapeInterval    = 10 * time.Second
+	DefaultScrapeRetries     = 2
+	DefaultScrapeBackoff     = 500 * time.Millisecond
+	DefaultScrapeBackoffMax  = 5 * time.Second
+	DefaultRestartBackoff    = 5 * time.Second
+	DefaultRestartBackoffMax = 60 * time.Second
+
+	// DefaultHistorySamples bounds the traffic history kept in the
+	// state file to one week at 1-minute resolution.
+	DefaultHistorySamples = 7 * 24 * 60
+
+	// CurrentStateSchemaVersion is written to every saved TrafficState
+	// and checked by loadState so future fields can be added without
+	// breaking older state files.
+	CurrentStateSchemaVersion = 2
 )
 
-// httpClient is used for metrics scraping with a timeout
-var httpClient = &http.Client{Timeout: httpTimeout}
+// bandwidthSteps are the percent-of-quota thresholds at which the
+// shaper is tuned down, stepping linearly from 4x MinBandwidthMbps
+// toward MinBandwidthMbps so the last step equals it (see
+// applyShaperStepLocked). They must be ascending.
+var bandwidthSteps = []int{60, 70, 80, 90}
+
+// TrafficSample is one point-in-time observation of traffic usage,
+// recorded at (roughly) each scrape interval into TrafficState.History.
+type TrafficSample struct {
+	Timestamp   time.Time `json:"timestamp"`
+	BytesUsed   int64     `json:"bytesUsed"`
+	IsThrottled bool      `json:"isThrottled"`
+}
 
 type TrafficState struct {
+	// SchemaVersion identifies the shape of this struct as written.
+	// State files from before this field existed unmarshal with it as
+	// zero, which loadState treats as schema version 1 (no History).
+	SchemaVersion   int       `json:"schemaVersion"`
 	PeriodStartTime time.Time `json:"periodStartTime"`
 	BytesUsed       int64     `json:"bytesUsed"`
 	IsThrottled     bool      `json:"isThrottled"`
+
+	// History is an append-only (bounded, down-sampled) record of past
+	// samples, kept in the state file for post-hoc debugging of usage
+	// and throttle behavior (the --monitor-metrics-addr metrics do not
+	// read it). It is capped at
+	// cfg.HistorySamples; once full, the oldest half is down-sampled
+	// to make room rather than dropped outright.
+	History []TrafficSample `json:"history,omitempty"`
 }
 
 type Config struct {
@@ -51,7 +94,43 @@ type Config struct {
 	MinBandwidthMbps          float64
 	DataDir                   string
 	MetricsAddr               string
-	ConduitArgs               []string
+	ShaperBackend             string
+	ShaperIface               string
+
+	// MonitorMetricsAddr, if set, serves the supervisor's own
+	// Prometheus metrics (conduit_monitor_*) on a separate registry
+	// and address from the conduit child's --metrics-addr.
+	MonitorMetricsAddr string
+
+	// HistorySamples bounds the number of TrafficSample entries kept
+	// in the state file's History.
+	HistorySamples int
+
+	ScrapeTimeout     time.Duration
+	ScrapeInterval    time.Duration
+	ScrapeRetries     int
+	ScrapeBackoff     time.Duration
+	ScrapeBackoffMax  time.Duration
+	RestartBackoff    time.Duration
+	RestartBackoffMax time.Duration
+
+	// TrafficSource selects how bytes transferred are measured:
+	// metrics|nic. Iface is required for nic.
+	TrafficSource        string
+	Iface                string
+	TrafficSourceCompare bool
+
+	// ConfigFile, if set, is re-read on SIGHUP and via the control
+	// socket's /reload endpoint to live-tune traffic limits.
+	ConfigFile string
+
+	// SimulateHTTPFailureRate, when > 0, probabilistically fails
+	// scrapeBytesUsed and the child shutdown/restart path with
+	// synthetic errors, for exercising unstable-network behavior in
+	// tests. Hidden: not printed by fs.PrintDefaults usage text.
+	SimulateHTTPFailureRate float64
+
+	ConduitArgs []string
 }
 
 func main() {
@@ -90,127 +169,199 @@ func looksLikeValue(s string) bool {
 	return err == nil
 }
 
-// isDataDirFlag checks if arg is specifically the --data-dir or -d flag
-func isDataDirFlag(arg string) bool {
-	return arg == "--data-dir" || arg == "-d" ||
-		strings.HasPrefix(arg, "--data-dir=") || strings.HasPrefix(arg, "-d=")
-}
-
-// isMetricsAddrFlag checks if arg is specifically the --metrics-addr flag
-func isMetricsAddrFlag(arg string) bool {
-	return arg == "--metrics-addr" || strings.HasPrefix(arg, "--metrics-addr=")
-}
-
-func parseFlags() *Config {
-	cfg := &Config{}
-
-	// Define flags
-	flag.Float64Var(&cfg.TrafficLimitGB, "traffic-limit", 0, "Total traffic limit in GB (0 = unlimited)")
-	flag.IntVar(&cfg.TrafficPeriodDays, "traffic-period", 0, "Time period in days for traffic limit")
-	flag.IntVar(&cfg.BandwidthThresholdPercent, "bandwidth-threshold", DefaultThreshold, "Throttle at this % of quota (60-90%)")
-	flag.IntVar(&cfg.MinConnections, "min-connections", 10, "Max clients when throttled")
-	flag.Float64Var(&cfg.MinBandwidthMbps, "min-bandwidth", 10, "Bandwidth in Mbps when throttled")
-	flag.StringVar(&cfg.DataDir, "data-dir", "./data", "Directory for keys and state")
-	flag.StringVar(&cfg.MetricsAddr, "metrics-addr", "127.0.0.1:9090", "Prometheus metrics listen address (required for monitoring)")
-
-	// Parse flags, but keep unknown flags for conduit
-	// We use a custom usage function to avoid failing on conduit flags
-	flag.Usage = func() {
-		fmt.Fprintf(os.Stderr, "Usage: conduit-monitor [monitor flags] -- [conduit flags]\n")
-		flag.PrintDefaults()
-	}
-
-	args := os.Args[1:]
-	monitorArgs := []string{}
-	conduitArgs := []string{"start"} // Default command
-
+// splitKnownArgs splits args into the tokens fs recognizes as one of
+// its own registered flags ("known", destined for fs.Parse) and
+// everything else ("passthrough", destined for conduit): positional
+// arguments, unrecognized flags together with their values, and
+// everything after a literal "--". This exists because
+// fs.ParseErrorsWhitelist.UnknownFlags only tells pflag not to error on
+// an unknown flag - it still drops the token instead of leaving it in
+// fs.Args(), so unknown flags have to be identified and preserved
+// before fs ever sees them.
+func splitKnownArgs(fs *pflag.FlagSet, args []string) (known, passthrough []string) {
 	for i := 0; i < len(args); i++ {
 		arg := args[i]
+
 		if arg == "--" {
-			// Handle args after separator
-			rest := args[i+1:]
-			// Skip redundant "start" if present (we already have it in conduitArgs)
-			if len(rest) > 0 && rest[0] == "start" {
-				rest = rest[1:]
-			}
-			conduitArgs = append(conduitArgs, rest...)
+			passthrough = append(passthrough, args[i+1:]...)
 			break
 		}
 
-		// Check if it's one of our monitor-only flags
-		if strings.HasPrefix(arg, "--traffic-limit") ||
-			strings.HasPrefix(arg, "--traffic-period") ||
-			strings.HasPrefix(arg, "--bandwidth-threshold") ||
-			strings.HasPrefix(arg, "--min-connections") ||
-			strings.HasPrefix(arg, "--min-bandwidth") {
-
-			// Add to monitor args to be parsed by flag set
-			monitorArgs = append(monitorArgs, arg)
-			if !strings.Contains(arg, "=") && i+1 < len(args) && looksLikeValue(args[i+1]) {
-				monitorArgs = append(monitorArgs, args[i+1])
-				i++
-			}
+		if arg == "-" || !strings.HasPrefix(arg, "-") {
+			passthrough = append(passthrough, arg)
 			continue
 		}
 
-		// Check for flags we share/need to know about (both monitor and conduit need these)
-		// Use exact match to avoid matching -debug, -data, etc.
-		if isDataDirFlag(arg) {
-			if strings.Contains(arg, "=") {
-				// Format: --data-dir=/path or -d=/path
-				monitorArgs = append(monitorArgs, arg)
-				conduitArgs = append(conduitArgs, arg)
-			} else if i+1 < len(args) && looksLikeValue(args[i+1]) {
-				// Format: --data-dir /path or -d /path
-				monitorArgs = append(monitorArgs, arg, args[i+1])
-				conduitArgs = append(conduitArgs, arg, args[i+1])
-				i++
-			} else {
-				// Flag without value - pass through to let conduit report error
-				conduitArgs = append(conduitArgs, arg)
-			}
-			continue
+		name := strings.TrimLeft(arg, "-")
+		hasValue := false
+		if eq := strings.IndexByte(name, '='); eq >= 0 {
+			name, hasValue = name[:eq], true
+		}
+
+		var flag *pflag.Flag
+		if strings.HasPrefix(arg, "--") {
+			flag = fs.Lookup(name)
+		} else if len(name) == 1 {
+			// ShorthandLookup panics on names longer than one
+			// character; longer single-dash tokens are unknown here.
+			flag = fs.ShorthandLookup(name)
 		}
-		if isMetricsAddrFlag(arg) {
-			if strings.Contains(arg, "=") {
-				// Format: --metrics-addr=host:port
-				monitorArgs = append(monitorArgs, arg)
-				conduitArgs = append(conduitArgs, arg)
-			} else if i+1 < len(args) && looksLikeValue(args[i+1]) {
-				// Format: --metrics-addr host:port
-				monitorArgs = append(monitorArgs, arg, args[i+1])
-				conduitArgs = append(conduitArgs, arg, args[i+1])
+
+		if flag == nil {
+			// Unknown flag: forward it, and its value if it looks like
+			// it takes one, straight to conduit instead of letting
+			// pflag silently drop it.
+			passthrough = append(passthrough, arg)
+			if !hasValue && i+1 < len(args) && looksLikeValue(args[i+1]) {
+				passthrough = append(passthrough, args[i+1])
 				i++
-			} else {
-				// Flag without value - pass through to let conduit report error
-				conduitArgs = append(conduitArgs, arg)
 			}
 			continue
 		}
 
-		// Add to conduit args (unknown flags pass through to conduit)
-		conduitArgs = append(conduitArgs, arg)
-		if !strings.Contains(arg, "=") && i+1 < len(args) && looksLikeValue(args[i+1]) {
-			conduitArgs = append(conduitArgs, args[i+1])
+		known = append(known, arg)
+		if !hasValue && flag.Value.Type() != "bool" && i+1 < len(args) {
+			// Non-bool flags always consume the next token as their
+			// value, the same way fs.Parse itself would.
+			known = append(known, args[i+1])
 			i++
 		}
 	}
+	return known, passthrough
+}
+
+// envOr* read an environment variable override for a flag's default,
+// falling back to def if the variable is unset or doesn't parse.
+func envOrString(name, def string) string {
+	if v, ok := os.LookupEnv(name); ok {
+		return v
+	}
+	return def
+}
+
+func envOrFloat64(name string, def float64) float64 {
+	if v, ok := os.LookupEnv(name); ok {
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			return f
+		}
+	}
+	return def
+}
+
+func envOrInt(name string, def int) int {
+	if v, ok := os.LookupEnv(name); ok {
+		if i, err := strconv.Atoi(v); err == nil {
+			return i
+		}
+	}
+	return def
+}
+
+func envOrBool(name string, def bool) bool {
+	if v, ok := os.LookupEnv(name); ok {
+		if b, err := strconv.ParseBool(v); err == nil {
+			return b
+		}
+	}
+	return def
+}
+
+func envOrDuration(name string, def time.Duration) time.Duration {
+	if v, ok := os.LookupEnv(name); ok {
+		if d, err := time.ParseDuration(v); err == nil {
+			return d
+		}
+	}
+	return def
+}
+
+// parseFlags parses os.Args with pflag, which gives POSIX-style short
+// aliases and lets us bind environment variable fallbacks onto each
+// flag's default. Unknown flags and anything after "--" are separated
+// out by splitKnownArgs before fs.Parse and passed straight through to
+// conduit.
+func parseFlags() *Config {
+	cfg := &Config{}
+
+	fs := pflag.NewFlagSet("monitor", pflag.ContinueOnError)
+	fs.ParseErrorsWhitelist.UnknownFlags = true
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: conduit-monitor [monitor flags] -- [conduit flags]\n")
+		fs.PrintDefaults()
+	}
 
-	// Parse our subset of flags
-	fs := flag.NewFlagSet("monitor", flag.ContinueOnError)
-	fs.Float64Var(&cfg.TrafficLimitGB, "traffic-limit", 0, "")
-	fs.IntVar(&cfg.TrafficPeriodDays, "traffic-period", 0, "")
-	fs.IntVar(&cfg.BandwidthThresholdPercent, "bandwidth-threshold", DefaultThreshold, "")
-	fs.IntVar(&cfg.MinConnections, "min-connections", 10, "")
-	fs.Float64Var(&cfg.MinBandwidthMbps, "min-bandwidth", 10, "")
-	fs.StringVar(&cfg.DataDir, "data-dir", "./data", "")
-	fs.StringVar(&cfg.DataDir, "d", "./data", "") // short flag alias
-	fs.StringVar(&cfg.MetricsAddr, "metrics-addr", "127.0.0.1:9090", "")
-	if err := fs.Parse(monitorArgs); err != nil {
+	fs.Float64VarP(&cfg.TrafficLimitGB, "traffic-limit", "l",
+		envOrFloat64("CONDUIT_MONITOR_TRAFFIC_LIMIT_GB", 0), "Total traffic limit in GB (0 = unlimited)")
+	fs.IntVarP(&cfg.TrafficPeriodDays, "traffic-period", "p",
+		envOrInt("CONDUIT_MONITOR_TRAFFIC_PERIOD_DAYS", 0), "Time period in days for traffic limit")
+	fs.IntVarP(&cfg.BandwidthThresholdPercent, "bandwidth-threshold", "t",
+		envOrInt("CONDUIT_MONITOR_BANDWIDTH_THRESHOLD", DefaultThreshold), "Throttle at this % of quota (60-90%)")
+	fs.IntVarP(&cfg.MinConnections, "min-connections", "c",
+		envOrInt("CONDUIT_MONITOR_MIN_CONNECTIONS", 10), "Max clients when throttled")
+	fs.Float64VarP(&cfg.MinBandwidthMbps, "min-bandwidth", "B",
+		envOrFloat64("CONDUIT_MONITOR_MIN_BANDWIDTH_MBPS", 10), "Bandwidth in Mbps when throttled")
+	fs.StringVarP(&cfg.DataDir, "data-dir", "d",
+		envOrString("CONDUIT_MONITOR_DATA_DIR", "./data"), "Directory for keys and state")
+	fs.StringVarP(&cfg.MetricsAddr, "metrics-addr", "m",
+		envOrString("CONDUIT_MONITOR_METRICS_ADDR", "127.0.0.1:9090"), "Prometheus metrics listen address (required for monitoring)")
+	fs.StringVar(&cfg.ShaperBackend, "shaper",
+		envOrString("CONDUIT_MONITOR_SHAPER", "none"), "Bandwidth shaping backend when throttling: tc|none (inproc is not wired into any data path yet)")
+	fs.StringVar(&cfg.ShaperIface, "shaper-iface",
+		envOrString("CONDUIT_MONITOR_SHAPER_IFACE", ""), "Network interface to shape (required for --shaper=tc)")
+	fs.DurationVar(&cfg.ScrapeTimeout, "scrape-timeout",
+		envOrDuration("CONDUIT_MONITOR_SCRAPE_TIMEOUT", httpTimeout), "Timeout for each metrics scrape request")
+	fs.DurationVar(&cfg.ScrapeInterval, "scrape-interval",
+		envOrDuration("CONDUIT_MONITOR_SCRAPE_INTERVAL", DefaultScrapeInterval), "Interval between metrics scrapes")
+	fs.IntVar(&cfg.ScrapeRetries, "scrape-retries",
+		envOrInt("CONDUIT_MONITOR_SCRAPE_RETRIES", DefaultScrapeRetries), "Number of retries for a failed metrics scrape")
+	fs.DurationVar(&cfg.ScrapeBackoff, "scrape-backoff",
+		envOrDuration("CONDUIT_MONITOR_SCRAPE_BACKOFF", DefaultScrapeBackoff), "Initial backoff between scrape retries")
+	fs.DurationVar(&cfg.ScrapeBackoffMax, "scrape-backoff-max",
+		envOrDuration("CONDUIT_MONITOR_SCRAPE_BACKOFF_MAX", DefaultScrapeBackoffMax), "Maximum backoff between scrape retries")
+	fs.DurationVar(&cfg.RestartBackoff, "restart-backoff",
+		envOrDuration("CONDUIT_MONITOR_RESTART_BACKOFF", DefaultRestartBackoff), "Initial backoff before restarting a crashed child")
+	fs.DurationVar(&cfg.RestartBackoffMax, "restart-backoff-max",
+		envOrDuration("CONDUIT_MONITOR_RESTART_BACKOFF_MAX", DefaultRestartBackoffMax), "Maximum backoff before restarting a crashed child")
+	fs.Float64Var(&cfg.SimulateHTTPFailureRate, "simulate-http-failure-rate",
+		envOrFloat64("CONDUIT_MONITOR_SIMULATE_HTTP_FAILURE_RATE", 0), "")
+	_ = fs.MarkHidden("simulate-http-failure-rate")
+	fs.StringVar(&cfg.TrafficSource, "traffic-source",
+		envOrString("CONDUIT_MONITOR_TRAFFIC_SOURCE", "metrics"), "How to measure bytes transferred: metrics|nic")
+	fs.StringVar(&cfg.Iface, "iface",
+		envOrString("CONDUIT_MONITOR_IFACE", ""), "Network interface to read byte counters from (required for --traffic-source=nic)")
+	fs.BoolVar(&cfg.TrafficSourceCompare, "traffic-source-compare",
+		envOrBool("CONDUIT_MONITOR_TRAFFIC_SOURCE_COMPARE", false), "Log the metrics and nic sources side-by-side instead of just the selected one")
+	fs.StringVar(&cfg.ConfigFile, "config",
+		envOrString("CONDUIT_MONITOR_CONFIG", ""), "YAML/JSON config file re-read on SIGHUP and via the control socket's /reload endpoint")
+	fs.StringVar(&cfg.MonitorMetricsAddr, "monitor-metrics-addr",
+		envOrString("CONDUIT_MONITOR_MONITOR_METRICS_ADDR", ""), "Listen address for the supervisor's own conduit_monitor_* Prometheus metrics (disabled if empty)")
+	fs.IntVar(&cfg.HistorySamples, "history-samples",
+		envOrInt("CONDUIT_MONITOR_HISTORY_SAMPLES", DefaultHistorySamples), "Maximum number of traffic history samples to retain in the state file")
+
+	// pflag's ParseErrorsWhitelist.UnknownFlags silently discards
+	// unrecognized flag tokens instead of leaving them in fs.Args(), so
+	// splitKnownArgs pre-separates the tokens fs actually owns from
+	// everything meant for conduit before handing the former to Parse.
+	known, passthroughArgs := splitKnownArgs(fs, os.Args[1:])
+	if err := fs.Parse(known); err != nil {
 		// Log but don't fatal - invalid flags will be caught by validation or conduit
 		log.Printf("[WARN] Failed to parse monitor flags: %v", err)
 	}
 
+	// Everything splitKnownArgs didn't recognize as one of the flags
+	// above - positional args, unknown flags (with their values), and
+	// anything after "--" - is passed straight through to conduit.
+	conduitArgs := []string{"start"}
+	rest := passthroughArgs
+	if len(rest) > 0 && rest[0] == "start" {
+		rest = rest[1:]
+	}
+	conduitArgs = append(conduitArgs, rest...)
+
+	// --data-dir and --metrics-addr are needed by conduit too.
+	conduitArgs = append(conduitArgs, "--data-dir", cfg.DataDir)
+	conduitArgs = append(conduitArgs, "--metrics-addr", cfg.MetricsAddr)
+
 	cfg.ConduitArgs = conduitArgs
 	return cfg
 }
@@ -231,6 +382,10 @@ func validateConfig(cfg *Config) error {
 	if cfg.MinBandwidthMbps <= 0 {
 		return fmt.Errorf("min-bandwidth must be positive")
 	}
+	if cfg.ShaperBackend == string(shaper.BackendInproc) {
+		return fmt.Errorf("shaper=inproc is not usable yet: no relay consumes its limiter, so it would " +
+			"silently disable throttling instead of applying it; use --shaper=tc or --shaper=none")
+	}
 	return nil
 }
 
@@ -253,20 +408,91 @@ type Supervisor struct {
 	stopChan        chan struct{}
 	restartChan     chan struct{}
 	metricsURL      string
+	httpClient      *http.Client
 	lastScrapeTotal int64 // Track last scraped value to calculate delta
+
+	shaper     shaper.Shaper
+	shaperStep int // index into bandwidthSteps of the currently applied step, -1 if none
+
+	restartFailures int       // consecutive crash-restarts, for exponential backoff
+	lastChildStart  time.Time // when the current/last child was started
+
+	primarySource trafficsource.TrafficSource
+	compareSource trafficsource.TrafficSource // non-nil only when cfg.TrafficSourceCompare
+
+	controlServer *http.Server
+	startTime     time.Time
+
+	monitorMetrics *monitorMetrics // non-nil only when cfg.MonitorMetricsAddr is set
 }
 
 func NewSupervisor(cfg *Config) *Supervisor {
-	return &Supervisor{
+	sh, err := shaper.New(shaper.Backend(cfg.ShaperBackend), cfg.ShaperIface)
+	if err != nil {
+		log.Printf("[WARN] Failed to initialize %q shaper, falling back to restart-based throttling: %v", cfg.ShaperBackend, err)
+		sh, _ = shaper.New(shaper.BackendNone, "")
+	}
+
+	s := &Supervisor{
 		cfg:         cfg,
 		stateFile:   filepath.Join(cfg.DataDir, StateFileName),
 		stopChan:    make(chan struct{}),
 		restartChan: make(chan struct{}, 1),
 		metricsURL:  fmt.Sprintf("http://%s/metrics", cfg.MetricsAddr),
+		httpClient:  &http.Client{Timeout: cfg.ScrapeTimeout},
+		shaper:      sh,
+		shaperStep:  -1,
+		startTime:   time.Now(),
+	}
+
+	if cfg.MonitorMetricsAddr != "" {
+		s.monitorMetrics = newMonitorMetrics()
+	}
+
+	s.primarySource = s.newTrafficSource(cfg.TrafficSource)
+	if cfg.TrafficSourceCompare {
+		if cfg.TrafficSource == "metrics" {
+			s.compareSource = s.newTrafficSource("nic")
+		} else {
+			s.compareSource = s.newTrafficSource("metrics")
+		}
+	}
+
+	return s
+}
+
+// newTrafficSource builds the TrafficSource named by kind ("metrics" or
+// "nic"), falling back to the metrics scraper for an unrecognized
+// value. There is deliberately no per-PID source: NICSource only has
+// interface-wide /proc/net/dev counters to work with, and a "pid" mode
+// built on top of it would just be "nic" under a misleading name.
+func (s *Supervisor) newTrafficSource(kind string) trafficsource.TrafficSource {
+	switch kind {
+	case "nic":
+		return trafficsource.NewNICSource(s.cfg.Iface)
+	case "metrics", "":
+		return &trafficsource.MetricsScraper{
+			URL:             s.metricsURL,
+			HTTPClient:      s.httpClient,
+			SimulateFailure: s.simulatedScrapeFailure,
+		}
+	default:
+		log.Printf("[WARN] Unknown --traffic-source %q, falling back to metrics", kind)
+		return &trafficsource.MetricsScraper{
+			URL:             s.metricsURL,
+			HTTPClient:      s.httpClient,
+			SimulateFailure: s.simulatedScrapeFailure,
+		}
 	}
 }
 
 func (s *Supervisor) Run() error {
+	defer func() {
+		if err := s.shaper.Close(); err != nil {
+			log.Printf("[WARN] Failed to close shaper: %v", err)
+		}
+	}()
+
 	// Load or initialize state
 	if err := s.loadState(); err != nil {
 		if os.IsNotExist(err) {
@@ -275,6 +501,7 @@ func (s *Supervisor) Run() error {
 			log.Printf("[WARN] Failed to load state, starting fresh: %v", err)
 		}
 		s.state = &TrafficState{
+			SchemaVersion:   CurrentStateSchemaVersion,
 			PeriodStartTime: time.Now(),
 			BytesUsed:       0,
 			IsThrottled:     false,
@@ -284,17 +511,55 @@ func (s *Supervisor) Run() error {
 		}
 	}
 
+	if err := s.startControlSocket(); err != nil {
+		log.Printf("[WARN] Failed to start control socket, live re-tuning via it will be unavailable: %v", err)
+	}
+	defer s.stopControlSocket()
+
+	if s.monitorMetrics != nil {
+		if err := s.monitorMetrics.startServer(s.cfg.MonitorMetricsAddr); err != nil {
+			log.Printf("[WARN] Failed to start monitor metrics server: %v", err)
+		} else {
+			defer func() {
+				shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
+				defer shutdownCancel()
+				if err := s.monitorMetrics.shutdown(shutdownCtx); err != nil {
+					log.Printf("[WARN] Failed to shut down monitor metrics server: %v", err)
+				}
+			}()
+			s.refreshMonitorMetrics()
+		}
+	}
+
 	// Handle signals
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 
+	sighupChan := make(chan os.Signal, 1)
+	signal.Notify(sighupChan, syscall.SIGHUP)
+
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-sighupChan:
+				log.Println("[INFO] Received SIGHUP, reloading config...")
+				if err := s.reloadConfig(); err != nil {
+					log.Printf("[WARN] Config reload failed: %v", err)
+				}
+			}
+		}
+	}()
+
 	// Start monitoring loop
 	go s.monitorLoop(ctx)
 
 	// Main loop to manage child process
+	firstStart := true
 	for {
 		// Check for stop/signal before starting child
 		select {
@@ -306,9 +571,13 @@ func (s *Supervisor) Run() error {
 		default:
 		}
 
-		// Prepare conduit arguments based on throttle state
+		// Prepare conduit arguments based on throttle state. MinConnections
+		// and MinBandwidthMbps are read under s.mu since reloadConfig can
+		// change them concurrently.
 		s.mu.Lock()
 		isThrottled := s.state.IsThrottled
+		minConnections := s.cfg.MinConnections
+		minBandwidthMbps := s.cfg.MinBandwidthMbps
 		s.mu.Unlock()
 
 		args := make([]string, len(s.cfg.ConduitArgs))
@@ -319,14 +588,20 @@ func (s *Supervisor) Run() error {
 			// Override flags for throttling
 			args = filterArgs(args, "--max-clients", "-m")
 			args = filterArgs(args, "--bandwidth", "-b")
-			args = append(args, "--max-clients", fmt.Sprintf("%d", s.cfg.MinConnections))
-			args = append(args, "--bandwidth", fmt.Sprintf("%.0f", s.cfg.MinBandwidthMbps))
+			args = append(args, "--max-clients", fmt.Sprintf("%d", minConnections))
+			args = append(args, "--bandwidth", fmt.Sprintf("%.0f", minBandwidthMbps))
 		} else {
 			log.Println("[INFO] Starting Conduit in NORMAL mode")
 		}
 
+		if s.monitorMetrics != nil && !firstStart {
+			s.monitorMetrics.childRestartsTotal.Inc()
+		}
+		firstStart = false
+
 		// Start child
 		s.mu.Lock()
+		s.lastChildStart = time.Now()
 		s.child = exec.Command("conduit", args...)
 		s.child.Stdout = os.Stdout
 		s.child.Stderr = os.Stderr
@@ -336,6 +611,10 @@ func (s *Supervisor) Run() error {
 		}
 		s.mu.Unlock()
 
+		if s.monitorMetrics != nil {
+			s.monitorMetrics.childUp.Set(1)
+		}
+
 		// Single goroutine calls Wait() - this is the ONLY place Wait() is called
 		waitErr := make(chan error, 1)
 		go func() {
@@ -345,10 +624,14 @@ func (s *Supervisor) Run() error {
 		// Wait for child exit, restart signal, or shutdown signal
 		select {
 		case err := <-waitErr:
+			if s.monitorMetrics != nil {
+				s.monitorMetrics.childUp.Set(0)
+			}
 			if err != nil {
 				log.Printf("[ERROR] Conduit exited with error: %v", err)
-				// Backoff before restart
-				time.Sleep(5 * time.Second)
+				backoff := s.nextRestartBackoff()
+				log.Printf("[INFO] Backing off %s before restart", backoff)
+				time.Sleep(backoff)
 			} else {
 				log.Println("[INFO] Conduit exited normally")
 				return nil // Exit if child exits cleanly
@@ -356,13 +639,22 @@ func (s *Supervisor) Run() error {
 		case <-s.restartChan:
 			log.Println("[INFO] Restarting Conduit to apply new settings...")
 			s.shutdownChild(waitErr)
+			if s.monitorMetrics != nil {
+				s.monitorMetrics.childUp.Set(0)
+			}
 			// Loop will continue and restart child
 		case <-sigChan:
 			log.Println("[INFO] Received signal, shutting down...")
 			s.shutdownChild(waitErr)
+			if s.monitorMetrics != nil {
+				s.monitorMetrics.childUp.Set(0)
+			}
 			return nil
 		case <-s.stopChan:
 			s.shutdownChild(waitErr)
+			if s.monitorMetrics != nil {
+				s.monitorMetrics.childUp.Set(0)
+			}
 			return nil
 		}
 	}
@@ -389,6 +681,17 @@ func (s *Supervisor) shutdownChild(waitErr <-chan error) {
 	// Try graceful shutdown first (ignore error - process may have already exited)
 	_ = child.Process.Signal(syscall.SIGTERM)
 
+	if err := s.simulatedScrapeFailure(); err != nil {
+		// Simulate the child's shutdown endpoint returning a 5xx, so
+		// the graceful wait below is skipped and we go straight to the
+		// forced-kill path, exercising the same code an unstable
+		// network would trigger.
+		log.Printf("[WARN] Simulated shutdown failure, forcing kill: %v", err)
+		_ = child.Process.Kill()
+		<-waitErr
+		return
+	}
+
 	// Wait for the single Wait() goroutine to return, with timeout
 	select {
 	case <-waitErr:
@@ -402,8 +705,29 @@ func (s *Supervisor) shutdownChild(waitErr <-chan error) {
 	}
 }
 
+// nextRestartBackoff returns the delay to wait before restarting a
+// crashed child, growing exponentially from cfg.RestartBackoff up to
+// cfg.RestartBackoffMax. The counter resets if the previous child
+// survived at least that long before crashing again.
+func (s *Supervisor) nextRestartBackoff() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.lastChildStart.IsZero() && time.Since(s.lastChildStart) >= s.cfg.RestartBackoffMax {
+		s.restartFailures = 0
+	}
+
+	backoff := s.cfg.RestartBackoff << s.restartFailures
+	if backoff <= 0 || backoff > s.cfg.RestartBackoffMax {
+		backoff = s.cfg.RestartBackoffMax
+	}
+	s.restartFailures++
+
+	return backoff
+}
+
 func (s *Supervisor) monitorLoop(ctx context.Context) {
-	ticker := time.NewTicker(10 * time.Second)
+	ticker := time.NewTicker(s.cfg.ScrapeInterval)
 	defer ticker.Stop()
 
 	// Initial check
@@ -422,9 +746,9 @@ func (s *Supervisor) monitorLoop(ctx context.Context) {
 func (s *Supervisor) checkTraffic() {
 	// 1. Check period expiration
 	now := time.Now()
-	periodDuration := time.Duration(s.cfg.TrafficPeriodDays) * 24 * time.Hour
 
 	s.mu.Lock()
+	periodDuration := time.Duration(s.cfg.TrafficPeriodDays) * 24 * time.Hour
 	periodEnd := s.state.PeriodStartTime.Add(periodDuration)
 	s.mu.Unlock()
 
@@ -437,16 +761,21 @@ func (s *Supervisor) checkTraffic() {
 		wasThrottled := s.state.IsThrottled
 		s.state.IsThrottled = false
 		s.lastScrapeTotal = 0 // Reset scrape counter
+		s.shaperStep = -1
+		if err := s.shaper.SetLimits(0, 0, 0); err != nil {
+			log.Printf("[WARN] Failed to reset shaper limits: %v", err)
+		}
 		s.mu.Unlock()
 
 		if err := s.saveState(); err != nil {
 			log.Printf("[WARN] Failed to save state after reset: %v", err)
 		}
 
-		if wasThrottled {
+		if wasThrottled && (s.cfg.ShaperBackend == "" || s.cfg.ShaperBackend == string(shaper.BackendNone)) {
 			// Trigger restart to restore normal capacity
 			s.triggerRestart()
 		}
+		s.refreshMonitorMetrics()
 		return
 	}
 
@@ -455,10 +784,14 @@ func (s *Supervisor) checkTraffic() {
 	if err != nil {
 		// Just log warning, don't crash. Conduit might be starting up.
 		// log.Printf("[WARN] Failed to scrape metrics: %v", err)
+		if s.monitorMetrics != nil {
+			s.monitorMetrics.scrapeErrorsTotal.Inc()
+		}
 		return
 	}
 
 	s.updateUsage(bytesUsed)
+	s.refreshMonitorMetrics()
 }
 
 func (s *Supervisor) updateUsage(currentSessionTotal int64) {
@@ -475,22 +808,43 @@ func (s *Supervisor) updateUsage(currentSessionTotal int64) {
 
 	if delta > 0 {
 		s.state.BytesUsed += delta
+		s.appendHistorySampleLocked()
 		if err := s.saveState(); err != nil {
 			log.Printf("[WARN] Failed to save state: %v", err)
 		}
 	}
 
-	// Check limits
 	limitBytes := int64(s.cfg.TrafficLimitGB * 1024 * 1024 * 1024)
-	thresholdBytes := int64(float64(limitBytes) * float64(s.cfg.BandwidthThresholdPercent) / 100.0)
+	percentUsed := 0
+	if limitBytes > 0 {
+		percentUsed = int(float64(s.state.BytesUsed) / float64(limitBytes) * 100.0)
+	}
 
-	if !s.state.IsThrottled && s.state.BytesUsed >= thresholdBytes {
-		log.Printf("[THROTTLE] Threshold reached (%d%%). Throttling...", s.cfg.BandwidthThresholdPercent)
-		s.state.IsThrottled = true
-		if err := s.saveState(); err != nil {
-			log.Printf("[WARN] Failed to save state: %v", err)
+	if s.cfg.ShaperBackend != "" && s.cfg.ShaperBackend != string(shaper.BackendNone) {
+		// Gradual shaping: re-tune the limiter live at each step
+		// instead of bouncing the child process.
+		step := -1
+		for i, pct := range bandwidthSteps {
+			if percentUsed >= pct {
+				step = i
+			}
+		}
+		if step != s.shaperStep {
+			s.shaperStep = step
+			s.applyShaperStepLocked(step)
+		}
+	} else {
+		// Legacy behavior: restart with --max-clients/--bandwidth
+		// overrides once the (single) threshold is crossed.
+		thresholdBytes := int64(float64(limitBytes) * float64(s.cfg.BandwidthThresholdPercent) / 100.0)
+		if !s.state.IsThrottled && s.state.BytesUsed >= thresholdBytes {
+			log.Printf("[THROTTLE] Threshold reached (%d%%). Throttling...", s.cfg.BandwidthThresholdPercent)
+			s.state.IsThrottled = true
+			if err := s.saveState(); err != nil {
+				log.Printf("[WARN] Failed to save state: %v", err)
+			}
+			needsRestart = true
 		}
-		needsRestart = true
 	}
 	s.mu.Unlock()
 
@@ -500,6 +854,38 @@ func (s *Supervisor) updateUsage(currentSessionTotal int64) {
 	}
 }
 
+// applyShaperStepLocked re-tunes the shaper's bandwidth limit for the
+// given step index into bandwidthSteps (-1 meaning unthrottled). It
+// must be called with s.mu held.
+func (s *Supervisor) applyShaperStepLocked(step int) {
+	fullBytesPerSec := s.cfg.MinBandwidthMbps * 1024 * 1024 / 8 * 4 // headroom above the floor, see below
+	floorBytesPerSec := s.cfg.MinBandwidthMbps * 1024 * 1024 / 8
+
+	if step < 0 {
+		log.Println("[SHAPE] Below threshold, removing bandwidth cap")
+		s.state.IsThrottled = false
+		if err := s.shaper.SetLimits(0, 0, 0); err != nil {
+			log.Printf("[WARN] Failed to reset shaper limits: %v", err)
+		}
+	} else {
+		// Linearly interpolate down to the configured floor as we
+		// climb through the steps, so the last step equals MinBandwidthMbps.
+		frac := float64(step+1) / float64(len(bandwidthSteps))
+		bytesPerSec := fullBytesPerSec - frac*(fullBytesPerSec-floorBytesPerSec)
+		burst := int(bytesPerSec) // 1 second worth of burst
+
+		log.Printf("[SHAPE] %d%% threshold crossed, limiting to %.0f bytes/sec", bandwidthSteps[step], bytesPerSec)
+		s.state.IsThrottled = true
+		if err := s.shaper.SetLimits(bytesPerSec, bytesPerSec, burst); err != nil {
+			log.Printf("[WARN] Failed to apply shaper limits: %v", err)
+		}
+	}
+
+	if err := s.saveState(); err != nil {
+		log.Printf("[WARN] Failed to save state: %v", err)
+	}
+}
+
 func (s *Supervisor) triggerRestart() {
 	select {
 	case s.restartChan <- struct{}{}:
@@ -508,64 +894,149 @@ func (s *Supervisor) triggerRestart() {
 	}
 }
 
+// scrapeBytesUsed queries the selected TrafficSource for the total
+// bytes transferred so far, retrying up to cfg.ScrapeRetries times
+// with exponential backoff and jitter on failure. When
+// cfg.TrafficSourceCompare is set, the other source is also queried
+// and logged alongside the primary one, best-effort.
 func (s *Supervisor) scrapeBytesUsed() (int64, error) {
-	// Use client with timeout to prevent hanging
-	resp, err := httpClient.Get(s.metricsURL)
-	if err != nil {
-		return 0, err
-	}
-	defer resp.Body.Close()
+	bytesUsed, err := s.scrapeFrom(s.primarySource)
 
-	// Check HTTP status code
-	if resp.StatusCode != http.StatusOK {
-		return 0, fmt.Errorf("metrics returned status %d", resp.StatusCode)
-	}
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return 0, err
+	if s.compareSource != nil {
+		if compareBytes, compareErr := s.scrapeFrom(s.compareSource); compareErr != nil {
+			log.Printf("[COMPARE] %s scrape failed: %v", s.compareSource.Name(), compareErr)
+		} else {
+			log.Printf("[COMPARE] %s=%d %s=%d", s.primarySource.Name(), bytesUsed, s.compareSource.Name(), compareBytes)
+		}
 	}
 
-	// Parse Prometheus text format
-	lines := strings.Split(string(body), "\n")
-	var up, down int64
+	return bytesUsed, err
+}
 
-	for _, line := range lines {
-		if strings.HasPrefix(line, "#") {
-			continue
-		}
-		// Use HasPrefix with space for exact metric name matching
-		// This prevents matching "conduit_bytes_uploaded_total" etc.
-		if strings.HasPrefix(line, "conduit_bytes_uploaded ") {
-			parts := strings.Fields(line)
-			if len(parts) >= 2 {
-				if val, err := strconv.ParseFloat(parts[1], 64); err == nil {
-					up = int64(val)
-				}
+func (s *Supervisor) scrapeFrom(source trafficsource.TrafficSource) (int64, error) {
+	var lastErr error
+	backoff := s.cfg.ScrapeBackoff
+
+	for attempt := 0; attempt <= s.cfg.ScrapeRetries; attempt++ {
+		if attempt > 0 {
+			jitter := time.Duration(rand.Int63n(int64(backoff) + 1))
+			time.Sleep(backoff/2 + jitter/2)
+			backoff *= 2
+			if backoff > s.cfg.ScrapeBackoffMax {
+				backoff = s.cfg.ScrapeBackoffMax
 			}
 		}
-		if strings.HasPrefix(line, "conduit_bytes_downloaded ") {
-			parts := strings.Fields(line)
-			if len(parts) >= 2 {
-				if val, err := strconv.ParseFloat(parts[1], 64); err == nil {
-					down = int64(val)
-				}
-			}
+
+		bytesUsed, err := source.TotalBytes()
+		if err == nil {
+			return bytesUsed, nil
 		}
+		lastErr = err
+		log.Printf("[WARN] %s scrape attempt %d/%d failed: %v", source.Name(), attempt+1, s.cfg.ScrapeRetries+1, err)
+	}
+
+	return 0, lastErr
+}
+
+// simulatedScrapeFailure probabilistically returns a synthetic error,
+// gated by cfg.SimulateHTTPFailureRate, for exercising unstable-network
+// behavior in tests without a real flaky endpoint.
+func (s *Supervisor) simulatedScrapeFailure() error {
+	if s.cfg.SimulateHTTPFailureRate > 0 && rand.Float64() < s.cfg.SimulateHTTPFailureRate {
+		return fmt.Errorf("simulated scrape failure (rate=%.2f)", s.cfg.SimulateHTTPFailureRate)
+	}
+	return nil
+}
+
+// appendHistorySampleLocked records the current state as a new
+// TrafficSample and compacts History down to cfg.HistorySamples if it
+// has grown past the cap. It must be called with s.mu held.
+func (s *Supervisor) appendHistorySampleLocked() {
+	s.state.History = append(s.state.History, TrafficSample{
+		Timestamp:   time.Now(),
+		BytesUsed:   s.state.BytesUsed,
+		IsThrottled: s.state.IsThrottled,
+	})
+
+	max := s.cfg.HistorySamples
+	if max <= 0 {
+		max = DefaultHistorySamples
+	}
+	s.state.History = compactHistory(s.state.History, max)
+}
+
+// compactHistory bounds history to at most max samples. Once full, the
+// older half is down-sampled (every other sample dropped) rather than
+// discarded outright, halving its resolution to make room for new
+// full-resolution samples at the tail.
+func compactHistory(history []TrafficSample, max int) []TrafficSample {
+	if max <= 0 || len(history) <= max {
+		return history
+	}
+
+	keepRecent := max / 2
+	older := history[:len(history)-keepRecent]
+	recent := history[len(history)-keepRecent:]
+
+	downsampled := make([]TrafficSample, 0, len(older)/2+1)
+	for i := 0; i < len(older); i += 2 {
+		downsampled = append(downsampled, older[i])
+	}
+
+	return append(downsampled, recent...)
+}
+
+// refreshMonitorMetrics updates the conduit_monitor_* gauges from the
+// current state and config. It is a no-op when --monitor-metrics-addr
+// wasn't set.
+func (s *Supervisor) refreshMonitorMetrics() {
+	if s.monitorMetrics == nil {
+		return
 	}
 
-	return up + down, nil
+	s.mu.Lock()
+	bytesUsed := s.state.BytesUsed
+	isThrottled := s.state.IsThrottled
+	periodEnd := s.state.PeriodStartTime.Add(time.Duration(s.cfg.TrafficPeriodDays) * 24 * time.Hour)
+	limitBytes := int64(s.cfg.TrafficLimitGB * 1024 * 1024 * 1024)
+	s.mu.Unlock()
+
+	s.monitorMetrics.bytesUsed.Set(float64(bytesUsed))
+	s.monitorMetrics.bytesLimit.Set(float64(limitBytes))
+	s.monitorMetrics.periodSecondsRemaining.Set(time.Until(periodEnd).Seconds())
+	setBool(s.monitorMetrics.throttled, isThrottled)
 }
 
+// loadState reads and decodes the state file, then normalizes its
+// SchemaVersion to CurrentStateSchemaVersion. Versions at or below the
+// current one need no migration: unknown-to-them fields are silently
+// ignored by json.Unmarshal, and schema 1 (or the zero value, from
+// before SchemaVersion existed) simply has no History, which the zero
+// value of that field already represents correctly. A version newer
+// than this binary understands is logged, since silently downgrading
+// it could mean discarding fields a newer build wrote.
 func (s *Supervisor) loadState() error {
 	data, err := os.ReadFile(s.stateFile)
 	if err != nil {
 		return err
 	}
-	return json.Unmarshal(data, &s.state)
+	if err := json.Unmarshal(data, &s.state); err != nil {
+		return err
+	}
+	switch {
+	case s.state.SchemaVersion > CurrentStateSchemaVersion:
+		log.Printf("[WARN] state file %s has schema version %d, newer than this binary's %d; proceeding, but fields it added may be lost on save",
+			s.stateFile, s.state.SchemaVersion, CurrentStateSchemaVersion)
+	case s.state.SchemaVersion < CurrentStateSchemaVersion:
+		// Schema versions <= CurrentStateSchemaVersion need no
+		// migration in this tree; see the doc comment above.
+	}
+	s.state.SchemaVersion = CurrentStateSchemaVersion
+	return nil
 }
 
 func (s *Supervisor) saveState() error {
+	s.state.SchemaVersion = CurrentStateSchemaVersion
 	data, err := json.MarshalIndent(s.state, "", "  ")
 	if err != nil {
 		return err
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const monitorMetricsNamespace = "conduit_monitor"
+
+// monitorMetrics exposes the supervisor's own Prometheus metrics, on a
+// separate registry/address from the conduit child it supervises, so
+// the supervisor is observable even when the child's metrics endpoint
+// is down.
+type monitorMetrics struct {
+	registry *prometheus.Registry
+
+	bytesUsed              prometheus.Gauge
+	bytesLimit             prometheus.Gauge
+	periodSecondsRemaining prometheus.Gauge
+	throttled              prometheus.Gauge
+	scrapeErrorsTotal      prometheus.Counter
+	childRestartsTotal     prometheus.Counter
+	childUp                prometheus.Gauge
+
+	server *http.Server
+}
+
+func newMonitorMetrics() *monitorMetrics {
+	registry := prometheus.NewRegistry()
+
+	m := &monitorMetrics{
+		registry: registry,
+		bytesUsed: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: monitorMetricsNamespace,
+			Name:      "bytes_used",
+			Help:      "Bytes used in the current traffic period",
+		}),
+		bytesLimit: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: monitorMetricsNamespace,
+			Name:      "bytes_limit",
+			Help:      "Configured traffic limit in bytes for the current period",
+		}),
+		periodSecondsRemaining: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: monitorMetricsNamespace,
+			Name:      "period_seconds_remaining",
+			Help:      "Seconds remaining until the current traffic period resets",
+		}),
+		throttled: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: monitorMetricsNamespace,
+			Name:      "throttled",
+			Help:      "Whether the child is currently throttled (1) or not (0)",
+		}),
+		scrapeErrorsTotal: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: monitorMetricsNamespace,
+			Name:      "scrape_errors_total",
+			Help:      "Total number of failed traffic-source scrapes",
+		}),
+		childRestartsTotal: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: monitorMetricsNamespace,
+			Name:      "child_restarts_total",
+			Help:      "Total number of times the supervised conduit child has been restarted",
+		}),
+		childUp: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: monitorMetricsNamespace,
+			Name:      "child_up",
+			Help:      "Whether the supervised conduit child is currently running (1) or not (0)",
+		}),
+	}
+
+	registry.MustRegister(
+		m.bytesUsed,
+		m.bytesLimit,
+		m.periodSecondsRemaining,
+		m.throttled,
+		m.scrapeErrorsTotal,
+		m.childRestartsTotal,
+		m.childUp,
+	)
+
+	return m
+}
+
+// startServer starts serving /metrics for this registry at addr,
+// mirroring metrics.Metrics.StartServer's pre-bind-then-serve pattern.
+func (m *monitorMetrics) startServer(addr string) error {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{}))
+
+	m.server = &http.Server{
+		Addr:         addr,
+		Handler:      mux,
+		ReadTimeout:  10 * time.Second,
+		WriteTimeout: 10 * time.Second,
+		IdleTimeout:  10 * time.Second,
+	}
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to bind monitor metrics address %s: %w", addr, err)
+	}
+
+	go func() {
+		if err := m.server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			log.Printf("[ERROR] Monitor metrics server error: %v", err)
+		}
+	}()
+
+	return nil
+}
+
+func (m *monitorMetrics) shutdown(ctx context.Context) error {
+	if m.server == nil {
+		return nil
+	}
+	return m.server.Shutdown(ctx)
+}
+
+func setBool(g prometheus.Gauge, b bool) {
+	if b {
+		g.Set(1)
+	} else {
+		g.Set(0)
+	}
+}
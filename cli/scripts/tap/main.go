@@ -0,0 +1,102 @@
+// Command tap listens for the dnstap-style structured event stream
+// produced by metrics.StreamEventSink and pretty-prints each event as
+// it arrives. There is no `conduit` CLI/subcommand framework in this
+// tree yet for a `conduit tap` subcommand to live under, so this ships
+// as a standalone script alongside cli/scripts/monitor, in the same
+// style, until one exists.
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"time"
+
+	"github.com/Psiphon-Inc/conduit/cli/internal/metrics"
+	"github.com/spf13/pflag"
+)
+
+func main() {
+	var unixPath, tcpAddr string
+	fs := pflag.NewFlagSet("tap", pflag.ExitOnError)
+	fs.StringVar(&unixPath, "listen", "", "Unix-domain socket path to listen on for the event stream")
+	fs.StringVar(&tcpAddr, "tcp", "", "TCP address to listen on for the event stream, instead of --listen")
+	fs.Parse(os.Args[1:])
+
+	if unixPath == "" && tcpAddr == "" {
+		log.Fatal("[ERROR] One of --listen or --tcp is required")
+	}
+
+	network, addr := "unix", unixPath
+	if tcpAddr != "" {
+		network, addr = "tcp", tcpAddr
+	}
+
+	if network == "unix" {
+		_ = os.Remove(addr)
+	}
+
+	listener, err := net.Listen(network, addr)
+	if err != nil {
+		log.Fatalf("[ERROR] Failed to listen on %s %s: %v", network, addr, err)
+	}
+	defer listener.Close()
+
+	log.Printf("[INFO] Listening for events on %s %s", network, addr)
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			log.Printf("[ERROR] Accept failed: %v", err)
+			continue
+		}
+		go handleConn(conn)
+	}
+}
+
+func handleConn(conn net.Conn) {
+	defer conn.Close()
+
+	for {
+		payload, err := metrics.ReadFrame(conn)
+		if err != nil {
+			if err != io.EOF {
+				log.Printf("[WARN] Failed to read frame: %v", err)
+			}
+			return
+		}
+
+		event, err := metrics.UnmarshalEvent(payload)
+		if err != nil {
+			// Tolerate a frame we can't fully parse (e.g. an unknown
+			// future event type with fields we don't recognize) and
+			// keep reading the stream.
+			log.Printf("[WARN] Failed to decode event: %v", err)
+			continue
+		}
+
+		printEvent(event)
+	}
+}
+
+func printEvent(e metrics.Event) {
+	ts := time.Unix(0, e.TimestampNS).UTC().Format(time.RFC3339Nano)
+	fmt.Printf("%s %-20s country=%s client=%s up=%d down=%d reason=%q trace_id=%s\n",
+		ts, eventTypeName(e.Type), e.CountryCode, hex.EncodeToString(e.ClientIDHash), e.BytesUp, e.BytesDown, e.Reason, e.TraceID)
+}
+
+func eventTypeName(t metrics.EventType) string {
+	switch t {
+	case metrics.EventClientConnected:
+		return "CLIENT_CONNECTED"
+	case metrics.EventClientDisconnected:
+		return "CLIENT_DISCONNECTED"
+	case metrics.EventFilterDecision:
+		return "FILTER_DECISION"
+	default:
+		return fmt.Sprintf("UNKNOWN(%d)", t)
+	}
+}